@@ -0,0 +1,62 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/dcilke/durl/pkg/build"
+)
+
+type BuildCmd struct {
+	File string `short:"f" long:"file" value-name:"FILE" description:"Read the description from FILE, - for stdin"`
+}
+
+func (c *BuildCmd) Execute(args []string) error {
+	if c.File != "" || len(args) == 0 {
+		if len(args) > 0 {
+			return usageError("--file and arguments are mutually exclusive")
+		}
+		// a YAML document spans lines, so the whole input is one spec
+		data, err := readSpec(c.File)
+		if err != nil {
+			return err
+		}
+		s, err := buildURL(data)
+		if err != nil {
+			return err
+		}
+		fmt.Println(render(s))
+		return nil
+	}
+	return inputs(args, func(arg string) (string, bool, error) {
+		s, err := buildURL([]byte(arg))
+		return render(s), err == nil, err
+	})
+}
+
+func readSpec(name string) ([]byte, error) {
+	var data []byte
+	var err error
+	if name == "" || name == "-" {
+		data, err = io.ReadAll(os.Stdin)
+	} else {
+		data, err = os.ReadFile(name)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("unable to read description: %w", err)
+	}
+	return data, nil
+}
+
+func buildURL(data []byte) (string, error) {
+	spec, err := build.Parse(data)
+	if err != nil {
+		return "", fmt.Errorf("unable to parse description: %w", err)
+	}
+	s, err := spec.String()
+	if err != nil {
+		return "", fmt.Errorf("unable to build url: %w", err)
+	}
+	return s, nil
+}
@@ -0,0 +1,212 @@
+package main
+
+import (
+	"os"
+	"strings"
+	"sync"
+	"unicode/utf8"
+
+	"github.com/dcilke/durl/pkg/urlax"
+)
+
+type OutputOptions struct {
+	Color string `long:"color" value-name:"WHEN" choice:"auto" choice:"always" choice:"never" default:"auto" description:"Highlight URL components; auto colors only a terminal and honors NO_COLOR"`
+	Elide int    `long:"elide" value-name:"WIDTH" description:"Shorten URLs longer than WIDTH by eliding the middle, keeping the host and the tail"`
+}
+
+// ANSI SGR parameters for each kind of token.
+var tokenColors = map[urlax.TokenKind]string{
+	urlax.TokenScheme:     "36",
+	urlax.TokenUser:       "33",
+	urlax.TokenPassword:   "31",
+	urlax.TokenHost:       "1;32",
+	urlax.TokenPort:       "32",
+	urlax.TokenPath:       "34",
+	urlax.TokenOpaque:     "34",
+	urlax.TokenQueryKey:   "35",
+	urlax.TokenQueryValue: "93",
+	urlax.TokenFragment:   "90",
+}
+
+const (
+	escapeColor  = "4"       // underline valid percent-escapes
+	invalidColor = "1;97;41" // white on red for malformed escapes
+	resetColor   = "\x1b[0m"
+)
+
+var stdoutIsTerminal = sync.OnceValue(func() bool { return isTerminal(os.Stdout) })
+
+func isTerminal(f *os.File) bool {
+	fi, err := f.Stat()
+	return err == nil && fi.Mode()&os.ModeCharDevice != 0
+}
+
+// colorEnabled reports whether URLs written to stdout are highlighted.
+func (o *OutputOptions) colorEnabled() bool {
+	return o.colorEnabledFor(stdoutIsTerminal)
+}
+
+// colorEnabledFor reports whether URLs written to a file that isTerminal
+// describes are highlighted.
+func (o *OutputOptions) colorEnabledFor(isTerminal func() bool) bool {
+	switch o.Color {
+	case "always":
+		return true
+	case "never":
+		return false
+	}
+	return os.Getenv("NO_COLOR") == "" && isTerminal()
+}
+
+// render prepares a URL for display, eliding and highlighting it as
+// requested. CSV and JSON output is never decorated.
+func render(s string) string {
+	if cmd.CSVOptions.enabled() || cmd.JSONPath != "" {
+		return s
+	}
+	return renderColor(s, cmd.OutputOptions.colorEnabled())
+}
+
+// renderColor is render for output that is not written to stdout, with
+// color decided by the caller.
+func renderColor(s string, color bool) string {
+	o := &cmd.OutputOptions
+	if !color && (o.Elide <= 0 || len(s) <= o.Elide) {
+		return s
+	}
+	tokens := urlax.Tokenize(s)
+	if o.Elide > 0 && len(s) > o.Elide {
+		tokens = elide(tokens, o.Elide)
+	}
+	var buf strings.Builder
+	for _, t := range tokens {
+		if color {
+			writeColored(&buf, t)
+		} else {
+			buf.WriteString(t.Text)
+		}
+	}
+	return buf.String()
+}
+
+// elide shortens tokens to about width bytes. The scheme and authority
+// are kept, then the end of the URL, with the middle replaced with "…".
+// At least a third of the width is kept for the end, shortening the start
+// of the host when the authority alone is too long.
+func elide(tokens []urlax.Token, width int) []urlax.Token {
+	head, headLen := 0, 0
+	for ; head < len(tokens); head++ {
+		t := tokens[head]
+		if t.Kind >= urlax.TokenPath || t.Kind == urlax.TokenDelim && (t.Text == "?" || t.Text == "#") {
+			break
+		}
+		headLen += len(t.Text)
+	}
+	restLen := 0
+	for _, t := range tokens[head:] {
+		restLen += len(t.Text)
+	}
+
+	tail := width - headLen - 1
+	if minTail := width / 3; tail < minTail {
+		tail = min(minTail, restLen)
+	}
+	if restLen <= tail {
+		tail = restLen
+	}
+	out := append([]urlax.Token(nil), tokens[:head]...)
+	if restLen > tail {
+		shortenHost(out, headLen+1+tail-width)
+		out = append(out, urlax.Token{Kind: urlax.TokenDelim, Text: "…"})
+	} else {
+		shortenHost(out, headLen+tail-width)
+	}
+
+	var kept []urlax.Token
+	for i := len(tokens) - 1; i >= head && tail > 0; i-- {
+		t := tokens[i]
+		if len(t.Text) > tail {
+			cut := len(t.Text) - tail
+			for cut < len(t.Text) && !utf8.RuneStart(t.Text[cut]) {
+				cut++
+			}
+			t.Text = t.Text[cut:]
+		}
+		tail -= len(t.Text)
+		kept = append(kept, t)
+	}
+	for i := len(kept) - 1; i >= 0; i-- {
+		out = append(out, kept[i])
+	}
+	return out
+}
+
+// shortenHost removes about n bytes from the start of the host token in
+// head, replacing them with "…" and keeping at least its last label.
+func shortenHost(head []urlax.Token, n int) {
+	if n <= 0 {
+		return
+	}
+	for i, t := range head {
+		if t.Kind != urlax.TokenHost {
+			continue
+		}
+		keep := len(t.Text) - n - 1
+		if last := len(t.Text) - strings.LastIndexByte(t.Text, '.') - 1; keep < last {
+			keep = last
+		}
+		if keep >= len(t.Text) {
+			return
+		}
+		cut := len(t.Text) - keep
+		for cut < len(t.Text) && !utf8.RuneStart(t.Text[cut]) {
+			cut++
+		}
+		head[i].Text = "…" + t.Text[cut:]
+		return
+	}
+}
+
+// writeColored writes a token in its color, marking percent-escapes inside
+// it.
+func writeColored(buf *strings.Builder, t urlax.Token) {
+	color, ok := tokenColors[t.Kind]
+	if !ok && !strings.Contains(t.Text, "%") {
+		buf.WriteString(t.Text)
+		return
+	}
+	sgr := func(params ...string) {
+		buf.WriteString("\x1b[")
+		buf.WriteString(strings.TrimSuffix(strings.Join(params, ";"), ";"))
+		buf.WriteByte('m')
+	}
+	start := func() {
+		sgr("0", color)
+	}
+
+	start()
+	s := t.Text
+	for {
+		i := strings.IndexByte(s, '%')
+		if i < 0 {
+			break
+		}
+		buf.WriteString(s[:i])
+		if i+2 < len(s) && ishex(s[i+1]) && ishex(s[i+2]) {
+			sgr(escapeColor)
+			buf.WriteString(s[i : i+3])
+			s = s[i+3:]
+		} else {
+			sgr(invalidColor)
+			buf.WriteByte('%')
+			s = s[i+1:]
+		}
+		start()
+	}
+	buf.WriteString(s)
+	buf.WriteString(resetColor)
+}
+
+func ishex(c byte) bool {
+	return '0' <= c && c <= '9' || 'a' <= c && c <= 'f' || 'A' <= c && c <= 'F'
+}
@@ -0,0 +1,55 @@
+package main
+
+import (
+	"strings"
+	"testing"
+	"unicode/utf8"
+
+	"github.com/dcilke/durl/pkg/urlax"
+	"github.com/stretchr/testify/require"
+)
+
+func elided(s string, width int) string {
+	var buf strings.Builder
+	for _, t := range elide(urlax.Tokenize(s), width) {
+		buf.WriteString(t.Text)
+	}
+	return buf.String()
+}
+
+func TestElide(t *testing.T) {
+	for _, tt := range []struct {
+		name  string
+		in    string
+		width int
+		out   string
+	}{
+		{"keeps the authority and the tail", "https://example.com/a/b/c/d/e/f/g?x=1", 30, "https://example.com…/e/f/g?x=1"},
+		{"query without path", "https://example.com/abcdefghij?q=1", 26, "https://…mple.com…ghij?q=1"},
+		{"width below the authority", "https://very.long.subdomain.example.com/path/to/thing?x=1", 30, "https://…xample.com…/thing?x=1"},
+		{"host down to the last label", "https://very.long.subdomain.example.com/path/to/thing?x=1", 12, "https://…com…?x=1"},
+		{"last label is kept whole", "https://a.b.c.d.e.f.g.h.example.com/p", 10, "https://…com/p"},
+		{"tail fits after shortening", "https://very.long.subdomain.example.com/p", 30, "https://…bdomain.example.com/p"},
+		{"multi-byte tail cut mid-rune", "https://example.com/ééééééééé", 27, "https://…mple.com…éééé"},
+		{"multi-byte tail", "https://example.com/ééééééééé", 30, "https://example.com…ééééé"},
+		{"multi-byte host", "https://ééééééééééé.ééé/ééééééééé", 29, "https://…é.ééé…éééé"},
+		{"multi-byte host cut mid-rune", "https://bücherbücherbücher.example/ä/ö/ü", 31, "https://…cher.example/ä/ö/ü"},
+		{"userinfo and port", "https://user:pw@example.com:8443/a/b/c/d/e/f?x=1", 40, "https://user:pw@….com:8443…b/c/d/e/f?x=1"},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			require.Equal(t, tt.out, elided(tt.in, tt.width))
+		})
+	}
+}
+
+func TestElideValidUTF8(t *testing.T) {
+	for _, s := range []string{
+		"https://ééééééééééé.ééé/ééééééééé",
+		"https://bücherbücherbücher.example/ä/ö/ü?q=日本語#片",
+	} {
+		for width := 1; width <= len(s); width++ {
+			out := elided(s, width)
+			require.True(t, utf8.ValidString(out), "%d %q", width, out)
+		}
+	}
+}
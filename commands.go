@@ -0,0 +1,83 @@
+package main
+
+import (
+	"net/url"
+
+	"github.com/dcilke/durl/pkg/urlax"
+)
+
+type DecodeCmd struct {
+	Profile string `long:"profile" value-name:"NAME" description:"Decode each input as a plain string the way the named library would, e.g. js-component, python-quote-plus or java"`
+}
+
+func (c *DecodeCmd) Execute(args []string) error {
+	if c.Profile != "" {
+		return runProfile(args, c.Profile, urlax.Profile.Unescape)
+	}
+	return runURLs(args, func(u *url.URL) (string, error) {
+		return render(urlax.Decode(u)), nil
+	})
+}
+
+type EncodeCmd struct {
+	Profile string `long:"profile" value-name:"NAME" description:"Encode each input as a plain string the way the named library would, e.g. js-component, python-quote-plus or java"`
+}
+
+func (c *EncodeCmd) Execute(args []string) error {
+	if c.Profile != "" {
+		return runProfile(args, c.Profile, func(p urlax.Profile, s string) (string, error) {
+			return p.Escape(s), nil
+		})
+	}
+	return runURLs(args, func(u *url.URL) (string, error) {
+		return render(u.String()), nil
+	})
+}
+
+// runProfile applies fn with the named encoding profile to every input,
+// treating inputs as plain strings rather than URLs.
+func runProfile(args []string, name string, fn func(p urlax.Profile, s string) (string, error)) error {
+	if cmd.CSVOptions.enabled() || cmd.JSONPath != "" {
+		return usageError("--profile cannot be combined with --csv, --tsv or --json-path")
+	}
+	p, err := urlax.LookupProfile(name)
+	if err != nil {
+		return usageError("%w", err)
+	}
+	return inputs(args, func(s string) (string, bool, error) {
+		out, err := fn(p, s)
+		return out, err == nil, err
+	})
+}
+
+type GetCmd struct {
+	Args struct {
+		Component string   `positional-arg-name:"COMPONENT" required:"yes" description:"scheme, user, password, host, hostname, port, path, query, fragment or opaque"`
+		URLs      []string `positional-arg-name:"URL"`
+	} `positional-args:"yes"`
+}
+
+func (c *GetCmd) Execute(args []string) error {
+	if _, err := component(&url.URL{}, c.Args.Component); err != nil {
+		return usageError("%w", err)
+	}
+	return runURLs(c.Args.URLs, func(u *url.URL) (string, error) {
+		return component(u, c.Args.Component)
+	})
+}
+
+type SetCmd struct {
+	Args struct {
+		Component string   `positional-arg-name:"COMPONENT" required:"yes" description:"scheme, user, password, host, hostname, port, path, query or fragment"`
+		Value     string   `positional-arg-name:"VALUE" required:"yes" description:"Decoded value to set"`
+		URLs      []string `positional-arg-name:"URL"`
+	} `positional-args:"yes"`
+}
+
+func (c *SetCmd) Execute(args []string) error {
+	// setting a component is the same as the matching --set-* transform
+	if err := cmd.TransformOptions.set(c.Args.Component, c.Args.Value); err != nil {
+		return usageError("%w", err)
+	}
+	return runURLs(c.Args.URLs, nil)
+}
@@ -0,0 +1,83 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/dcilke/durl/pkg/urlax"
+)
+
+type CompatCmd struct{}
+
+func (c *CompatCmd) Execute(args []string) error {
+	return inputs(args, c.process)
+}
+
+// process reports how every known encoder would encode arg, grouping the
+// encoders that agree, and which encoders could have produced arg.
+func (c *CompatCmd) process(arg string) (string, bool, error) {
+	var buf strings.Builder
+	w := tabwriter.NewWriter(&buf, 0, 4, 2, ' ', 0)
+
+	// encoders producing the same output share a group letter
+	groups := map[string]byte{}
+	fmt.Fprintln(w, "encoder\tgroup\toutput")
+	for _, p := range urlax.Profiles {
+		out := p.Escape(arg)
+		g, ok := groups[out]
+		if !ok {
+			g = 'A' + byte(len(groups))
+			groups[out] = g
+		}
+		fmt.Fprintf(w, "%s\t%c\t%s\n", p.Name, g, out)
+	}
+	w.Flush()
+	if len(groups) == 1 {
+		buf.WriteString("all encoders agree\n")
+	} else {
+		fmt.Fprintf(&buf, "%d distinct encodings\n", len(groups))
+	}
+
+	buf.WriteByte('\n')
+	w = tabwriter.NewWriter(&buf, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(w, "produced by\tdecodes to")
+	found := false
+	for _, p := range producers(arg) {
+		decoded, _ := p.Unescape(arg)
+		fmt.Fprintf(w, "%s\t%s\n", p.Name, decoded)
+		found = true
+	}
+	if !found {
+		fmt.Fprintln(w, "none\tno known encoder produces this exact text")
+	}
+	w.Flush()
+	return strings.TrimSuffix(buf.String(), "\n"), true, nil
+}
+
+// producers returns the profiles whose encoder reproduces s from its own
+// decoding of s, ignoring the case of hex digits.
+func producers(s string) []urlax.Profile {
+	want := upperEscapes(s)
+	var ps []urlax.Profile
+	for _, p := range urlax.Profiles {
+		decoded, err := p.Unescape(s)
+		if err == nil && p.Escape(decoded) == want {
+			ps = append(ps, p)
+		}
+	}
+	return ps
+}
+
+// upperEscapes uppercases the hex digits of the percent-escapes in s.
+func upperEscapes(s string) string {
+	b := []byte(s)
+	for i := 0; i+2 < len(b); i++ {
+		if b[i] == '%' && ishex(b[i+1]) && ishex(b[i+2]) {
+			b[i+1] = strings.ToUpper(string(b[i+1]))[0]
+			b[i+2] = strings.ToUpper(string(b[i+2]))[0]
+			i += 2
+		}
+	}
+	return string(b)
+}
@@ -0,0 +1,38 @@
+package main
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+)
+
+// components lists the URL parts that can be extracted by name.
+var components = []string{"scheme", "user", "password", "host", "hostname", "port", "path", "query", "fragment", "opaque"}
+
+// component returns the decoded value of the named URL part.
+func component(u *url.URL, name string) (string, error) {
+	switch strings.ToLower(name) {
+	case "scheme":
+		return u.Scheme, nil
+	case "user", "username":
+		return u.User.Username(), nil
+	case "password":
+		password, _ := u.User.Password()
+		return password, nil
+	case "host":
+		return u.Host, nil
+	case "hostname":
+		return u.Hostname(), nil
+	case "port":
+		return u.Port(), nil
+	case "path":
+		return u.Path, nil
+	case "query":
+		return u.RawQuery, nil
+	case "fragment":
+		return u.Fragment, nil
+	case "opaque":
+		return u.Opaque, nil
+	}
+	return "", fmt.Errorf("unknown component %q, expected one of %s", name, strings.Join(components, ", "))
+}
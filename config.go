@@ -0,0 +1,61 @@
+package main
+
+import (
+	"slices"
+	"strings"
+
+	"github.com/dcilke/durl/pkg/config"
+	flags "github.com/jessevdk/go-flags"
+)
+
+// unconfigurable are the options the config file cannot set.
+var unconfigurable = map[string]bool{"preset": true, "interactive": true, "help": true}
+
+// applyConfig installs the defaults of the config file, and the options of
+// the preset named by --preset, as option defaults so that the command line
+// overrides both.
+func applyConfig(parser *flags.Parser, args []string) error {
+	// the preset has to be known before the command line is parsed
+	var pre struct {
+		Preset string `long:"preset"`
+	}
+	flags.NewParser(&pre, flags.IgnoreUnknown|flags.PassDoubleDash).ParseArgs(args)
+
+	path, err := config.Path()
+	if err != nil {
+		if pre.Preset != "" {
+			return usageError("unable to locate config: %w", err)
+		}
+		return nil
+	}
+	c, err := config.Load(path)
+	if err != nil {
+		return usageError("unable to read config %s: %w", path, err)
+	}
+	settings := c.Defaults
+	if pre.Preset != "" {
+		preset, ok := c.Presets[pre.Preset]
+		if !ok {
+			names := c.PresetNames()
+			if len(names) == 0 {
+				return usageError("unknown preset %q, %s defines no presets", pre.Preset, path)
+			}
+			return usageError("unknown preset %q, expected one of %s", pre.Preset, strings.Join(names, ", "))
+		}
+		// preset values replace the defaults of the same options
+		settings = append(settings[:len(settings):len(settings)], preset...)
+	}
+	for _, s := range settings {
+		opt := parser.FindOptionByLongName(s.Key)
+		if opt == nil || unconfigurable[s.Key] {
+			return usageError("%s:%d: unknown option %q", path, s.Line, s.Key)
+		}
+		for _, v := range s.Values {
+			if len(opt.Choices) > 0 && !slices.Contains(opt.Choices, v) {
+				return usageError("%s:%d: invalid %s %q, expected one of %s", path, s.Line, s.Key, v, strings.Join(opt.Choices, ", "))
+			}
+		}
+		opt.Default = s.Values
+	}
+	return nil
+}
@@ -0,0 +1,98 @@
+package main
+
+import (
+	"errors"
+	"io"
+	"net/url"
+	"strings"
+
+	"github.com/dcilke/durl/pkg/stream"
+	"github.com/dcilke/durl/pkg/table"
+)
+
+type CSVOptions struct {
+	CSV    bool   `long:"csv" description:"Read stdin as CSV and process a single column"`
+	TSV    bool   `long:"tsv" description:"Read stdin as TSV and process a single column"`
+	Column string `long:"column" value-name:"NAME|INDEX" description:"Column holding the URL, by header name or 1-based index" default:"1"`
+	Header bool   `long:"header" description:"Treat the first row as a header (implied when --column is a name)"`
+	Append string `long:"append" value-name:"COMPONENTS" description:"Append comma separated URL components as new columns instead of replacing the field"`
+}
+
+// enabled reports whether tabular input was requested.
+func (o *CSVOptions) enabled() bool {
+	return o.CSV || o.TSV
+}
+
+// validate reports invalid CSV options before any input is read.
+func (o *CSVOptions) validate() error {
+	if o.CSV && o.TSV {
+		return usageError("--csv and --tsv are mutually exclusive")
+	}
+	if _, _, err := table.Column(o.Column); err != nil {
+		return usageError("%w", err)
+	}
+	for _, name := range o.appends() {
+		if _, err := component(&url.URL{}, name); err != nil {
+			return usageError("invalid --append: %w", err)
+		}
+	}
+	return nil
+}
+
+func (o *CSVOptions) appends() []string {
+	if o.Append == "" {
+		return nil
+	}
+	return strings.Split(o.Append, ",")
+}
+
+// processCSV reads records from r, transforms the selected column of each
+// with fn and writes the records to w. Rows whose URL cannot be parsed are
+// written unchanged, with empty appended columns.
+func (c *Cmd) processCSV(r io.Reader, w io.Writer, fn urlFunc) (stream.Stats, error) {
+	o := &c.CSVOptions
+	if err := o.validate(); err != nil {
+		return stream.Stats{}, err
+	}
+	appends := o.appends()
+	opts := table.Options{
+		Column:   o.Column,
+		Header:   o.Header,
+		Append:   appends,
+		FailFast: c.FailFast,
+		OnError:  printError,
+	}
+	if o.TSV {
+		opts.Comma = '\t'
+	}
+	stats, err := table.Process(r, w, opts, func(field string) (string, []string, error) {
+		return transformField(field, appends, fn)
+	})
+	if errors.Is(err, table.ErrColumnNotFound) {
+		return stats, usageError("%w", err)
+	}
+	return stats, err
+}
+
+// transformField applies the transforms and fn to a field and extracts
+// the appended components. On error the field is returned unchanged.
+func transformField(field string, appends []string, fn urlFunc) (string, []string, error) {
+	extra := make([]string, len(appends))
+	s, u, err := transformURL(field)
+	if err != nil {
+		return field, extra, err
+	}
+	for i, name := range appends {
+		if extra[i], err = component(u, name); err != nil {
+			return field, make([]string, len(appends)), err
+		}
+	}
+	if fn == nil {
+		return s, extra, nil
+	}
+	out, err := fn(u)
+	if err != nil {
+		return field, make([]string, len(appends)), err
+	}
+	return out, extra, nil
+}
@@ -0,0 +1,27 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/dcilke/durl/pkg/dsn"
+)
+
+type DsnCmd struct {
+	To string `short:"t" long:"to" description:"Output format" choice:"url" choice:"libpq" choice:"mysql-go" choice:"jdbc" choice:"ado" default:"url"`
+}
+
+func (c *DsnCmd) Execute(args []string) error {
+	return inputs(args, c.process)
+}
+
+func (c *DsnCmd) process(arg string) (string, bool, error) {
+	d, err := dsn.Parse(arg)
+	if err != nil {
+		return "", false, fmt.Errorf("unable to parse connection string %q: %w", arg, err)
+	}
+	s, err := d.Format(dsn.Format(c.To))
+	if err != nil {
+		return "", false, fmt.Errorf("unable to format connection string %q: %w", arg, err)
+	}
+	return s, true, nil
+}
@@ -0,0 +1,80 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/dcilke/durl/pkg/stream"
+	flags "github.com/jessevdk/go-flags"
+)
+
+// Exit codes.
+const (
+	exitOK      = 0 // every input succeeded
+	exitFailure = 1 // the run itself failed, e.g. reading stdin
+	exitUsage   = 2 // invalid command line
+	exitParse   = 3 // every input failed, or --fail-fast stopped at a failure
+	exitPartial = 4 // some inputs failed and others succeeded
+)
+
+// exitError is an error with a specific exit code.
+type exitError struct {
+	code int
+	err  error
+}
+
+func (e *exitError) Error() string {
+	return e.err.Error()
+}
+
+func (e *exitError) Unwrap() error {
+	return e.err
+}
+
+// usageError reports an invalid combination of arguments.
+func usageError(format string, a ...any) error {
+	return &exitError{exitUsage, fmt.Errorf(format, a...)}
+}
+
+// printError writes err to stderr on its own line.
+func printError(err error) {
+	fmt.Fprintf(os.Stderr, "durl: %v\n", err)
+}
+
+// statsError summarizes the failures of a run, or returns nil when every
+// input succeeded.
+func statsError(s stream.Stats) error {
+	switch {
+	case s.Failed == 0:
+		return nil
+	case s.Stopped:
+		return &exitError{exitParse, fmt.Errorf("stopped at the first failed input after %d inputs", s.Records)}
+	case s.Failed == s.Records:
+		return &exitError{exitParse, fmt.Errorf("all %d inputs failed", s.Records)}
+	}
+	return &exitError{exitPartial, fmt.Errorf("%d of %d inputs failed", s.Failed, s.Records)}
+}
+
+// exitCode prints err and maps it to an exit code.
+func exitCode(err error) int {
+	if err == nil {
+		return exitOK
+	}
+	var fe *flags.Error
+	if errors.As(err, &fe) {
+		if fe.Type == flags.ErrHelp {
+			fmt.Fprintln(os.Stdout, fe.Message)
+			return exitOK
+		}
+		printError(fmt.Errorf("unable to parse arguments: %w (see --help)", err))
+		return exitUsage
+	}
+	var ee *exitError
+	if errors.As(err, &ee) {
+		printError(ee.err)
+		return ee.code
+	}
+	printError(err)
+	return exitFailure
+}
@@ -0,0 +1,144 @@
+package main
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/dcilke/durl/pkg/urlax"
+)
+
+type ExplainCmd struct{}
+
+func (c *ExplainCmd) Execute(args []string) error {
+	return runURLs(args, explain)
+}
+
+// node is a line of the explain tree.
+type node struct {
+	name     string
+	raw      string // escaped text as it appears in the URL
+	decoded  string
+	note     string
+	children []node
+}
+
+// explain renders u as a tree of its components, showing the escaped and
+// decoded text of each along with the parser flags that affect how u is
+// serialized.
+func explain(u *url.URL) (string, error) {
+	return explainHeader(u, render(u.String())), nil
+}
+
+// explainHeader is explain with the URL heading the tree already rendered.
+func explainHeader(u *url.URL, header string) string {
+	var root []node
+	root = append(root, node{name: "scheme", raw: u.Scheme, decoded: u.Scheme})
+	if u.Opaque != "" {
+		root = append(root, node{name: "opaque", raw: u.Opaque, decoded: u.Opaque})
+	} else {
+		root = append(root, userinfoNode(u), hostNode(u), pathNode(u))
+	}
+	root = append(root, queryNode(u), fragmentNode(u))
+
+	var buf strings.Builder
+	buf.WriteString(header)
+	buf.WriteByte('\n')
+	w := tabwriter.NewWriter(&buf, 0, 4, 2, ' ', 0)
+	writeNodes(w, root, "")
+	fmt.Fprintf(w, "flags\topaque=%t omit-host=%t force-query=%t\n", u.Opaque != "", u.OmitHost, u.ForceQuery)
+	w.Flush()
+	// empty trailing cells are padded, drop the padding
+	lines := strings.Split(strings.TrimSuffix(buf.String(), "\n"), "\n")
+	for i, line := range lines {
+		lines[i] = strings.TrimRight(line, " ")
+	}
+	return strings.Join(lines, "\n")
+}
+
+func userinfoNode(u *url.URL) node {
+	n := node{name: "userinfo"}
+	if u.User == nil {
+		return n
+	}
+	n.raw = u.User.String()
+	n.decoded = u.User.Username()
+	user := node{name: "user", raw: url.User(u.User.Username()).String(), decoded: u.User.Username()}
+	n.children = append(n.children, user)
+	if password, ok := u.User.Password(); ok {
+		n.decoded += ":" + password
+		raw := strings.TrimPrefix(url.UserPassword("", password).String(), ":")
+		n.children = append(n.children, node{name: "password", raw: raw, decoded: password})
+	}
+	return n
+}
+
+func hostNode(u *url.URL) node {
+	raw := (&url.URL{Host: u.Host}).String()
+	n := node{name: "host", raw: strings.TrimPrefix(raw, "//"), decoded: u.Host}
+	if u.Host != "" {
+		n.children = []node{
+			{name: "hostname", raw: u.Hostname(), decoded: u.Hostname()},
+			{name: "port", raw: u.Port(), decoded: u.Port()},
+		}
+	}
+	return n
+}
+
+func pathNode(u *url.URL) node {
+	n := node{name: "path", raw: u.EscapedPath(), decoded: u.Path}
+	if u.RawPath != "" {
+		n.note = "RawPath"
+	}
+	return n
+}
+
+func queryNode(u *url.URL) node {
+	n := node{name: "query", raw: u.RawQuery}
+	if d, err := url.QueryUnescape(u.RawQuery); err == nil {
+		n.decoded = d
+	} else {
+		n.decoded = u.RawQuery
+	}
+	for _, p := range urlax.ParseQuery(u.RawQuery).Params {
+		if p.RawKey == "" && !p.HasValue {
+			continue
+		}
+		child := node{name: p.Key(), raw: p.RawValue, decoded: p.Value()}
+		if !p.HasValue {
+			child.note = "no value"
+		}
+		n.children = append(n.children, child)
+	}
+	return n
+}
+
+func fragmentNode(u *url.URL) node {
+	n := node{name: "fragment", raw: u.EscapedFragment(), decoded: u.Fragment}
+	if u.RawFragment != "" {
+		n.note = "RawFragment"
+	}
+	return n
+}
+
+// writeNodes writes one tab separated row per node: the tree branch and
+// name, the raw text, the decoded text when it differs, and any note.
+func writeNodes(w *tabwriter.Writer, nodes []node, indent string) {
+	for i, n := range nodes {
+		branch, next := "├─ ", "│  "
+		if i == len(nodes)-1 {
+			branch, next = "└─ ", "   "
+		}
+		decoded := ""
+		if n.decoded != n.raw {
+			decoded = "→ " + n.decoded
+		}
+		note := ""
+		if n.note != "" {
+			note = "[" + n.note + "]"
+		}
+		fmt.Fprintf(w, "%s%s%s\t%s\t%s\t%s\n", indent, branch, n.name, n.raw, decoded, note)
+		writeNodes(w, n.children, indent+next)
+	}
+}
@@ -0,0 +1,51 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"runtime"
+
+	"github.com/dcilke/durl/pkg/fileurl"
+)
+
+type PathFromURLCmd struct {
+	Windows bool `short:"w" long:"windows" description:"Produce Windows paths (default on Windows)"`
+}
+
+func (c *PathFromURLCmd) Execute(args []string) error {
+	style := pathStyle(c.Windows)
+	return inputs(args, func(arg string) (string, bool, error) {
+		p, err := fileurl.ToPath(arg, style)
+		if err != nil {
+			return "", false, fmt.Errorf("unable to convert url %q: %w", arg, err)
+		}
+		return p, true, nil
+	})
+}
+
+type URLFromPathCmd struct {
+	Windows bool   `short:"w" long:"windows" description:"Read Windows paths (default on Windows)"`
+	Cwd     string `long:"cwd" description:"Directory to resolve relative paths against (default: working directory)"`
+}
+
+func (c *URLFromPathCmd) Execute(args []string) error {
+	style := pathStyle(c.Windows)
+	cwd := c.Cwd
+	if cwd == "" {
+		cwd, _ = os.Getwd()
+	}
+	return inputs(args, func(arg string) (string, bool, error) {
+		u, err := fileurl.FromPath(arg, style, cwd)
+		if err != nil {
+			return "", false, fmt.Errorf("unable to convert path %q: %w", arg, err)
+		}
+		return u, true, nil
+	})
+}
+
+func pathStyle(windows bool) fileurl.Style {
+	if windows || runtime.GOOS == "windows" {
+		return fileurl.Windows
+	}
+	return fileurl.POSIX
+}
@@ -0,0 +1,65 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"io"
+
+	"github.com/dcilke/durl/pkg/jsonpath"
+	"github.com/dcilke/durl/pkg/stream"
+)
+
+type JSONOptions struct {
+	JSONPath string `long:"json-path" value-name:"PATH" description:"Read JSON or NDJSON from stdin and process the string at PATH, e.g. .request.url or .links[].href"`
+}
+
+// processJSON reads a stream of JSON documents from r, transforms the URL
+// strings at the configured path with fn and writes each document to w on its own
+// line. Documents whose URLs cannot be processed are written unchanged.
+func (c *Cmd) processJSON(r io.Reader, w io.Writer, fn urlFunc) (stream.Stats, error) {
+	var stats stream.Stats
+	p, err := jsonpath.Parse(c.JSONPath)
+	if err != nil {
+		return stats, usageError("invalid --json-path: %w", err)
+	}
+
+	bw := bufio.NewWriter(w)
+	defer bw.Flush()
+	dec := json.NewDecoder(r)
+	for {
+		var doc json.RawMessage
+		if err := dec.Decode(&doc); err == io.EOF {
+			return stats, bw.Flush()
+		} else if err != nil {
+			return stats, err
+		}
+
+		stats.Records++
+		out, err := jsonpath.Rewrite(doc, p, func(s string) (string, error) {
+			s, u, err := transformURL(s)
+			if err != nil || fn == nil {
+				return s, err
+			}
+			return fn(u)
+		})
+		if err != nil {
+			stats.Failed++
+			// flush first so errors appear in order with the output
+			if err := bw.Flush(); err != nil {
+				return stats, err
+			}
+			printError(err)
+			if c.FailFast {
+				stats.Stopped = true
+				return stats, nil
+			}
+			out = doc
+		}
+		if _, err := bw.Write(out); err != nil {
+			return stats, err
+		}
+		if err := bw.WriteByte('\n'); err != nil {
+			return stats, err
+		}
+	}
+}
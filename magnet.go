@@ -0,0 +1,59 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/dcilke/durl/pkg/magnet"
+)
+
+type MagnetCmd struct {
+	JSON bool `short:"j" long:"json" description:"Print as JSON instead of a table"`
+}
+
+func (c *MagnetCmd) Execute(args []string) error {
+	return inputs(args, c.process)
+}
+
+func (c *MagnetCmd) process(arg string) (string, bool, error) {
+	m, err := magnet.Parse(arg)
+	if err != nil {
+		return "", false, fmt.Errorf("unable to parse magnet %q: %w", arg, err)
+	}
+
+	if c.JSON {
+		b, err := json.Marshal(m)
+		return string(b), err == nil, err
+	}
+
+	var buf strings.Builder
+	w := tabwriter.NewWriter(&buf, 0, 4, 2, ' ', 0)
+	for _, t := range m.Topics {
+		fmt.Fprintf(w, "topic\t%s\t%s\t%s\n", t.Type, t.Algorithm, t.Hash)
+	}
+	if m.DisplayName != "" {
+		fmt.Fprintf(w, "name\t%s\n", m.DisplayName)
+	}
+	if m.Length != nil {
+		fmt.Fprintf(w, "length\t%d\n", *m.Length)
+	}
+	for _, tr := range m.Trackers {
+		fmt.Fprintf(w, "tracker\t%s\n", tr)
+	}
+	for _, ws := range m.WebSeeds {
+		fmt.Fprintf(w, "webseed\t%s\n", ws)
+	}
+	for _, s := range m.Sources {
+		fmt.Fprintf(w, "source\t%s\n", s)
+	}
+	for _, k := range m.Keywords {
+		fmt.Fprintf(w, "keyword\t%s\n", k)
+	}
+	for _, p := range m.Params {
+		fmt.Fprintf(w, "%s\t%s\n", p.Key, p.Value)
+	}
+	w.Flush()
+	return strings.TrimSuffix(buf.String(), "\n"), true, nil
+}
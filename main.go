@@ -1,62 +1,179 @@
 package main
 
 import (
-	"bufio"
 	"fmt"
+	"net/url"
 	"os"
 
+	"github.com/dcilke/durl/pkg/stream"
 	"github.com/dcilke/durl/pkg/urlax"
 	flags "github.com/jessevdk/go-flags"
 )
 
 type Cmd struct {
-	Password bool `short:"p" long:"password" description:"Extract password from URL"`
-	Encode   bool `short:"e" long:"encode" description:"Encode URL"`
-	Decode   bool `short:"d" long:"decode" description:"Decode URL"`
+	Interactive bool   `short:"i" long:"interactive" description:"Explore and edit a single URL with line based commands, printing the result to stdout on exit"`
+	Preset      string `long:"preset" value-name:"NAME" description:"Apply the options of a preset from the config file"`
+
+	CSVOptions       `group:"CSV Options"`
+	JSONOptions      `group:"JSON Options"`
+	StreamOptions    `group:"Stream Options"`
+	OutputOptions    `group:"Output Options"`
+	TransformOptions `group:"Transform Options"`
+	ParamOptions     `group:"Query Parameter Options"`
+
+	Decode  DecodeCmd  `command:"decode" description:"Fully decode URLs"`
+	Encode  EncodeCmd  `command:"encode" description:"Fully encode URLs"`
+	Get     GetCmd     `command:"get" description:"Print a single component of URLs"`
+	Set     SetCmd     `command:"set" description:"Replace a single component of URLs"`
+	Explain ExplainCmd `command:"explain" description:"Break URLs down into their components (default)"`
+
+	Compat      CompatCmd      `command:"compat" description:"Compare how other languages' encoders would encode a string"`
+	Dsn         DsnCmd         `command:"dsn" description:"Convert database connection strings between formats"`
+	Magnet      MagnetCmd      `command:"magnet" description:"Inspect the topics, trackers and seeds of a magnet URI"`
+	PathFromURL PathFromURLCmd `command:"path-from-url" description:"Convert file: URLs to filesystem paths"`
+	URLFromPath URLFromPathCmd `command:"url-from-path" description:"Convert filesystem paths to file: URLs"`
+	QueryToJSON QueryToJSONCmd `command:"query-to-json" description:"Convert nested query strings such as a[b][]=c to JSON"`
+	JSONToQuery JSONToQueryCmd `command:"json-to-query" description:"Convert JSON objects to nested query strings"`
+	Build       BuildCmd       `command:"build" description:"Build escaped URLs from JSON or YAML descriptions of their components"`
+	Rewrite     RewriteCmd     `command:"rewrite" description:"Rewrite hosts and path prefixes from a mapping file"`
+	Urn         UrnCmd         `command:"urn" description:"Parse, validate and compare URNs (RFC 8141)"`
 }
 
+// cmd holds the parsed command line. Subcommands read the global options
+// from it.
+var cmd Cmd
+
+// urlFunc transforms a single parsed URL.
+type urlFunc func(u *url.URL) (string, error)
+
 func main() {
+	os.Exit(exitCode(run()))
+}
+
+func run() error {
 	// parse command line flags
-	var cmd Cmd
 	parser := flags.NewParser(&cmd, flags.HelpFlag|flags.PassDoubleDash)
-	parser.Usage = "[URL]"
-	url, err := parser.Parse()
-	if e, ok := err.(*flags.Error); ok && e.Type == flags.ErrHelp {
-		parser.WriteHelp(os.Stdout)
-		return
+	parser.Usage = "[OPTIONS]"
+	parser.LongDescription = "Command line URL utilities. URLs are read from the arguments, or one per " +
+		"line from stdin. Without a command, each URL is broken down into its components."
+	parser.SubcommandsOptional = true
+	parser.CommandHandler = func(command flags.Commander, args []string) error {
+		if command == nil {
+			return nil
+		}
+		if cmd.ParamOptions.enabled() || cmd.Interactive {
+			return usageError("--param, --params and --interactive cannot be combined with a command")
+		}
+		return command.Execute(args)
 	}
-	if err != nil {
-		fmt.Fprint(os.Stderr, fmt.Errorf("unable to parse arguments: %w", err))
+	if err := applyConfig(parser, os.Args[1:]); err != nil {
+		return err
+	}
+	args, err := parser.Parse()
+	if err != nil || parser.Active != nil {
+		// a subcommand has already run
+		return err
+	}
+	if cmd.Interactive {
+		return interactive(args)
+	}
+	if err := cmd.ParamOptions.validate(); err != nil {
+		return err
 	}
+	if cmd.ParamOptions.enabled() {
+		return runURLs(args, cmd.ParamOptions.extract)
+	}
+	if cmd.CSVOptions.enabled() || cmd.JSONPath != "" || cmd.TransformOptions.enabled() {
+		// print the URL itself, rewritten by any transforms; tabular input
+		// also gets the requested components appended
+		return runURLs(args, nil)
+	}
+	return runURLs(args, explain)
+}
 
-	if len(url) > 0 {
-		for _, arg := range url {
-			cmd.process(arg)
+// runURLs applies the transforms and then fn to every input URL, reading
+// them from the arguments, stdin records, or the selected CSV column or
+// JSON path. A nil fn outputs the transformed URL.
+func runURLs(args []string, fn urlFunc) error {
+	if err := cmd.TransformOptions.validate(); err != nil {
+		return err
+	}
+	if cmd.CSVOptions.enabled() || cmd.JSONPath != "" {
+		if len(args) > 0 {
+			return usageError("URL arguments cannot be combined with --csv, --tsv or --json-path")
+		}
+		if cmd.CSVOptions.enabled() && cmd.JSONPath != "" {
+			return usageError("--json-path cannot be combined with --csv or --tsv")
+		}
+		if err := cmd.StreamOptions.validate(); err != nil {
+			return err
 		}
-		return
 	}
-
-	scanner := bufio.NewScanner(os.Stdin)
-	for scanner.Scan() {
-		line := scanner.Text()
-		cmd.process(line)
+	if cmd.CSVOptions.enabled() {
+		stats, err := cmd.processCSV(stream.SkipBOM(os.Stdin), os.Stdout, fn)
+		if err != nil {
+			return fmt.Errorf("unable to process csv: %w", err)
+		}
+		return statsError(stats)
 	}
+	if cmd.JSONPath != "" {
+		stats, err := cmd.processJSON(stream.SkipBOM(os.Stdin), os.Stdout, fn)
+		if err != nil {
+			return fmt.Errorf("unable to process json: %w", err)
+		}
+		return statsError(stats)
+	}
+
+	return inputs(args, func(arg string) (string, bool, error) {
+		s, u, err := transformURL(arg)
+		if err != nil {
+			return "", false, err
+		}
+		if fn == nil {
+			return render(s), true, nil
+		}
+		out, err := fn(u)
+		return out, err == nil, err
+	})
 }
 
-func (c *Cmd) process(arg string) {
-	u, err := urlax.Parse(arg)
+// inputs runs fn over each argument, or over each record of stdin when
+// there are no arguments, and writes the results to stdout. The returned
+// error summarizes the inputs that failed.
+func inputs(args []string, fn stream.Func) error {
+	if err := cmd.StreamOptions.validate(); err != nil {
+		return err
+	}
+	opts := cmd.StreamOptions.options()
+	var stats stream.Stats
+	var err error
+	if len(args) > 0 {
+		stats, err = stream.RunSlice(args, os.Stdout, opts, fn)
+	} else {
+		stats, err = stream.Run(os.Stdin, os.Stdout, opts, fn)
+	}
 	if err != nil {
-		fmt.Fprint(os.Stderr, fmt.Errorf("unable to parse url %q: %w", arg, err))
-		return
+		return fmt.Errorf("unable to process input: %w", err)
+	}
+	return statsError(stats)
+}
+
+// transformURL applies the transform options to s and parses the result.
+func transformURL(s string) (string, *url.URL, error) {
+	if cmd.TransformOptions.enabled() {
+		var err error
+		if s, err = cmd.TransformOptions.apply(s); err != nil {
+			return "", nil, err
+		}
 	}
+	u, err := parseURL(s)
+	return s, u, err
+}
 
-	switch {
-	case c.Password:
-		password, _ := u.User.Password()
-		fmt.Println(password)
-	case c.Encode:
-		fmt.Println(u.String())
-	case c.Decode:
-		fmt.Println(urlax.Decode(u))
+func parseURL(s string) (*url.URL, error) {
+	u, err := urlax.Parse(s)
+	if err != nil {
+		return nil, fmt.Errorf("unable to parse url %q: %w", s, err)
 	}
+	return u, nil
 }
@@ -0,0 +1,86 @@
+package main
+
+import (
+	"fmt"
+	"net/url"
+	"path"
+	"strings"
+
+	"github.com/dcilke/durl/pkg/urlax"
+)
+
+type ParamOptions struct {
+	Param     []string `long:"param" value-name:"NAME" description:"Print the decoded value of the query parameter NAME, which may be a glob such as utm_*; repeatable"`
+	AllValues bool     `long:"all-values" description:"With --param, print every value of repeated parameters instead of the first"`
+	Params    bool     `long:"params" description:"List the decoded keys of the query parameters"`
+}
+
+// enabled reports whether parameters are to be printed instead of the URL.
+func (o *ParamOptions) enabled() bool {
+	return len(o.Param) > 0 || o.Params
+}
+
+func (o *ParamOptions) validate() error {
+	if len(o.Param) > 0 && o.Params {
+		return usageError("--param and --params are mutually exclusive")
+	}
+	if o.AllValues && len(o.Param) == 0 {
+		return usageError("--all-values requires --param")
+	}
+	for _, p := range o.Param {
+		if _, err := path.Match(p, ""); err != nil {
+			return usageError("invalid --param pattern %q: %w", p, err)
+		}
+	}
+	return nil
+}
+
+// bare reports whether only values are printed: a single --param naming
+// one key. Otherwise each line is a tab separated key and value.
+func (o *ParamOptions) bare() bool {
+	return len(o.Param) == 1 && !strings.ContainsAny(o.Param[0], `*?[\`)
+}
+
+// extract prints the keys, or the values of the selected parameters, one
+// per output record. A URL without any selected parameter fails.
+func (o *ParamOptions) extract(u *url.URL) (string, error) {
+	q := urlax.ParseQuery(u.RawQuery)
+	var lines []string
+	if o.Params {
+		seen := map[string]bool{}
+		for _, p := range q.Params {
+			if k := p.Key(); !seen[k] && (k != "" || p.HasValue) {
+				seen[k] = true
+				lines = append(lines, k)
+			}
+		}
+		return strings.Join(lines, cmd.StreamOptions.delimiter()), nil
+	}
+
+	seen := map[string]bool{}
+	for _, p := range q.Params {
+		k := p.Key()
+		if !o.matches(k) || seen[k] && !o.AllValues {
+			continue
+		}
+		seen[k] = true
+		if o.bare() {
+			lines = append(lines, p.Value())
+		} else {
+			lines = append(lines, k+"\t"+p.Value())
+		}
+	}
+	if len(lines) == 0 {
+		return "", fmt.Errorf("no parameter matching %s in %q", strings.Join(o.Param, ", "), u.String())
+	}
+	return strings.Join(lines, cmd.StreamOptions.delimiter()), nil
+}
+
+func (o *ParamOptions) matches(key string) bool {
+	for _, pattern := range o.Param {
+		if ok, _ := path.Match(pattern, key); ok {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,85 @@
+package main
+
+import (
+	"errors"
+	"net/url"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestParamExtract(t *testing.T) {
+	u, err := url.Parse("https://example.com/?utm_source=news&utm_medium=mail&id=1&id=2&q=a+b%26c&flag&=x&id=3")
+	require.NoError(t, err)
+	for _, tt := range []struct {
+		name string
+		o    ParamOptions
+		null bool
+		out  string
+	}{
+		{"bare value", ParamOptions{Param: []string{"q"}}, false, "a b&c"},
+		{"first value", ParamOptions{Param: []string{"id"}}, false, "1"},
+		{"all values", ParamOptions{Param: []string{"id"}, AllValues: true}, false, "1\n2\n3"},
+		{"glob", ParamOptions{Param: []string{"utm_*"}}, false, "utm_source\tnews\nutm_medium\tmail"},
+		{"several names", ParamOptions{Param: []string{"q", "id"}}, false, "id\t1\nq\ta b&c"},
+		{"several names, all values", ParamOptions{Param: []string{"q", "id"}, AllValues: true}, false, "id\t1\nid\t2\nq\ta b&c\nid\t3"},
+		{"bare key", ParamOptions{Param: []string{"flag"}}, false, ""},
+		{"character class", ParamOptions{Param: []string{"[iq]*"}}, false, "id\t1\nq\ta b&c"},
+		{"keys", ParamOptions{Params: true}, false, "utm_source\nutm_medium\nid\nq\nflag\n"},
+		{"null delimiter", ParamOptions{Param: []string{"id"}, AllValues: true}, true, "1\x002\x003"},
+		{"null delimited keys", ParamOptions{Params: true}, true, "utm_source\x00utm_medium\x00id\x00q\x00flag\x00"},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			defer func(null bool) { cmd.StreamOptions.Null = null }(cmd.StreamOptions.Null)
+			cmd.StreamOptions.Null = tt.null
+			require.NoError(t, tt.o.validate())
+			out, err := tt.o.extract(u)
+			require.NoError(t, err)
+			require.Equal(t, tt.out, out)
+		})
+	}
+}
+
+func TestParamExtractMissing(t *testing.T) {
+	u, err := url.Parse("https://example.com/?a=1")
+	require.NoError(t, err)
+	_, err = (&ParamOptions{Param: []string{"b*"}}).extract(u)
+	require.Error(t, err)
+
+	u, err = url.Parse("https://example.com/")
+	require.NoError(t, err)
+	out, err := (&ParamOptions{Params: true}).extract(u)
+	require.NoError(t, err)
+	require.Equal(t, "", out)
+}
+
+func TestParamBare(t *testing.T) {
+	for _, tt := range []struct {
+		param []string
+		bare  bool
+	}{
+		{[]string{"q"}, true},
+		{[]string{"q", "id"}, false},
+		{[]string{"utm_*"}, false},
+		{[]string{"i?"}, false},
+		{[]string{"[iq]"}, false},
+		{[]string{`a\*`}, false},
+	} {
+		require.Equal(t, tt.bare, (&ParamOptions{Param: tt.param}).bare(), "%q", tt.param)
+	}
+}
+
+func TestParamValidate(t *testing.T) {
+	for name, o := range map[string]ParamOptions{
+		"param and params":         {Param: []string{"a"}, Params: true},
+		"all values without param": {AllValues: true},
+		"bad pattern":              {Param: []string{"["}},
+	} {
+		t.Run(name, func(t *testing.T) {
+			err := o.validate()
+			var e *exitError
+			require.True(t, errors.As(err, &e))
+			require.Equal(t, exitUsage, e.code)
+		})
+	}
+}
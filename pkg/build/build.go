@@ -0,0 +1,268 @@
+// Package build assembles URLs from a structured description, escaping
+// each component with the rules of package urlax.
+package build
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/dcilke/durl/pkg/qs"
+	"github.com/dcilke/durl/pkg/urlax"
+)
+
+// Param is a query parameter of a Spec.
+type Param struct {
+	Key   string
+	Value string
+}
+
+// Spec describes a URL by its decoded components:
+//
+//	{
+//	  "scheme": "https",
+//	  "user": "deploy", "password": "p@ss/w:rd",
+//	  "host": "example.com", "port": 8443,
+//	  "path": ["api", "v1", "a/b"],
+//	  "query": [{"q": "x&y"}, {"tag": "a"}, {"tag": "b"}],
+//	  "fragment": "top"
+//	}
+//
+// The path is either a list of segments, in which "/" is escaped, or a
+// single string. The query is an object, whose array values repeat the
+// key, or a list of single key objects or [key, value] pairs, which keep
+// their order. Numbers and booleans are taken as strings.
+type Spec struct {
+	Scheme   string
+	User     *string
+	Password *string
+	Host     string
+	Port     string
+	Path     []string // segments, each escaped on its own
+	RawPath  *string  // a whole path, "/" is kept
+	Query    []Param
+	Fragment *string
+}
+
+// FromObject reads a spec from a decoded JSON or YAML object.
+func FromObject(o *qs.Object) (*Spec, error) {
+	s := &Spec{}
+	for _, k := range o.Keys {
+		v := o.Values[k]
+		var err error
+		switch k {
+		case "scheme":
+			s.Scheme, err = str(k, v)
+		case "user", "username":
+			s.User, err = optional(k, v)
+		case "password":
+			s.Password, err = optional(k, v)
+		case "host", "hostname":
+			s.Host, err = str(k, v)
+		case "port":
+			s.Port, err = str(k, v)
+		case "path":
+			err = s.setPath(v)
+		case "query":
+			s.Query, err = params(v)
+		case "fragment":
+			s.Fragment, err = optional(k, v)
+		default:
+			err = fmt.Errorf("unknown key %q, expected scheme, user, password, host, port, path, query or fragment", k)
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+	return s, nil
+}
+
+func str(key string, v any) (string, error) {
+	switch v := v.(type) {
+	case string:
+		return v, nil
+	case nil:
+		return "", nil
+	}
+	return "", fmt.Errorf("%s must be a string", key)
+}
+
+func optional(key string, v any) (*string, error) {
+	if v == nil {
+		return nil, nil
+	}
+	s, err := str(key, v)
+	return &s, err
+}
+
+func (s *Spec) setPath(v any) error {
+	switch v := v.(type) {
+	case string:
+		s.RawPath = &v
+		return nil
+	case []any:
+		for _, seg := range v {
+			seg, err := str("path segment", seg)
+			if err != nil {
+				return err
+			}
+			s.Path = append(s.Path, seg)
+		}
+		return nil
+	case nil:
+		return nil
+	}
+	return errors.New("path must be a string or a list of segments")
+}
+
+func params(v any) ([]Param, error) {
+	var ps []Param
+	add := func(k string, v any) error {
+		if vs, ok := v.([]any); ok {
+			for _, v := range vs {
+				if err := add1(&ps, k, v); err != nil {
+					return err
+				}
+			}
+			return nil
+		}
+		return add1(&ps, k, v)
+	}
+	switch v := v.(type) {
+	case *qs.Object:
+		for _, k := range v.Keys {
+			if err := add(k, v.Values[k]); err != nil {
+				return nil, err
+			}
+		}
+	case []any:
+		for _, item := range v {
+			switch item := item.(type) {
+			case *qs.Object:
+				if len(item.Keys) != 1 {
+					return nil, errors.New("query list items must have a single key")
+				}
+				if err := add(item.Keys[0], item.Values[item.Keys[0]]); err != nil {
+					return nil, err
+				}
+			case []any:
+				if len(item) != 2 {
+					return nil, errors.New("query pairs must have a key and a value")
+				}
+				k, err := str("query key", item[0])
+				if err != nil {
+					return nil, err
+				}
+				if err := add(k, item[1]); err != nil {
+					return nil, err
+				}
+			default:
+				return nil, errors.New("query list items must be objects or [key, value] pairs")
+			}
+		}
+	case nil:
+	default:
+		return nil, errors.New("query must be an object or a list")
+	}
+	return ps, nil
+}
+
+func add1(ps *[]Param, k string, v any) error {
+	value, err := str("query value", v)
+	if err != nil {
+		return err
+	}
+	*ps = append(*ps, Param{k, value})
+	return nil
+}
+
+// String assembles the escaped URL.
+func (s *Spec) String() (string, error) {
+	if !validScheme(s.Scheme) {
+		return "", fmt.Errorf("invalid scheme %q", s.Scheme)
+	}
+	for i := 0; i < len(s.Port); i++ {
+		if s.Port[i] < '0' || s.Port[i] > '9' {
+			return "", fmt.Errorf("invalid port %q", s.Port)
+		}
+	}
+
+	var buf strings.Builder
+	if s.Scheme != "" {
+		buf.WriteString(s.Scheme)
+		buf.WriteByte(':')
+	}
+	authority := s.Host != "" || s.User != nil || s.Password != nil || s.Port != ""
+	if authority {
+		buf.WriteString("//")
+		if s.User != nil || s.Password != nil {
+			if s.User != nil {
+				buf.WriteString(urlax.Escape(*s.User, urlax.UserinfoComponent))
+			}
+			if s.Password != nil {
+				buf.WriteByte(':')
+				buf.WriteString(urlax.Escape(*s.Password, urlax.UserinfoComponent))
+			}
+			buf.WriteByte('@')
+		}
+		host := s.Host
+		if strings.Contains(host, ":") && !strings.HasPrefix(host, "[") {
+			host = "[" + host + "]"
+		}
+		buf.WriteString(urlax.Escape(host, urlax.HostComponent))
+		if s.Port != "" {
+			buf.WriteByte(':')
+			buf.WriteString(s.Port)
+		}
+	}
+
+	switch {
+	case s.RawPath != nil:
+		if authority && *s.RawPath != "" && !strings.HasPrefix(*s.RawPath, "/") {
+			buf.WriteByte('/')
+		}
+		buf.WriteString(urlax.Escape(*s.RawPath, urlax.PathComponent))
+	case s.Path != nil:
+		for _, seg := range s.Path {
+			buf.WriteByte('/')
+			buf.WriteString(urlax.Escape(seg, urlax.PathSegmentComponent))
+		}
+	}
+
+	if len(s.Query) > 0 {
+		q := &urlax.Query{}
+		for _, p := range s.Query {
+			q.Add(p.Key, p.Value)
+		}
+		buf.WriteByte('?')
+		buf.WriteString(q.String())
+	}
+	if s.Fragment != nil {
+		buf.WriteByte('#')
+		buf.WriteString(urlax.Escape(*s.Fragment, urlax.FragmentComponent))
+	}
+
+	// check the result the way every other command reads URLs
+	u, err := urlax.ParseLossless(buf.String())
+	if err != nil {
+		return "", err
+	}
+	return u.String(), nil
+}
+
+// validScheme reports whether s is empty or a scheme as RFC 3986 defines it.
+func validScheme(s string) bool {
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		switch {
+		case 'a' <= c && c <= 'z' || 'A' <= c && c <= 'Z':
+		case '0' <= c && c <= '9' || c == '+' || c == '-' || c == '.':
+			if i == 0 {
+				return false
+			}
+		default:
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,341 @@
+package build
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/dcilke/durl/pkg/qs"
+)
+
+// Parse reads a spec from a JSON object or from YAML.
+func Parse(data []byte) (*Spec, error) {
+	var o *qs.Object
+	var err error
+	if bytes.HasPrefix(bytes.TrimSpace(data), []byte("{")) {
+		o, err = qs.Decode(data)
+	} else {
+		o, err = ParseYAML(data)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return FromObject(o)
+}
+
+// ParseYAML reads the subset of YAML a spec needs: nested block mappings
+// and sequences, flow sequences and mappings of scalars, plain, single and
+// double quoted scalars and comments. Anchors, tags and multi-line
+// scalars are not supported. Scalars are read as strings, except null and
+// ~ which are nil.
+func ParseYAML(data []byte) (*qs.Object, error) {
+	var lines []yamlLine
+	for n, text := range strings.Split(string(data), "\n") {
+		text = strings.TrimRight(text, " \t\r")
+		trimmed := strings.TrimLeft(text, " ")
+		if trimmed == "" || strings.HasPrefix(trimmed, "#") || trimmed == "---" {
+			continue
+		}
+		if strings.HasPrefix(trimmed, "\t") {
+			return nil, fmt.Errorf("line %d: tabs are not allowed for indentation", n+1)
+		}
+		lines = append(lines, yamlLine{n + 1, len(text) - len(trimmed), trimmed})
+	}
+	p := &yamlParser{lines: lines}
+	if len(lines) == 0 {
+		return qs.NewObject(), nil
+	}
+	v, err := p.block(lines[0].indent)
+	if err != nil {
+		return nil, err
+	}
+	if p.i < len(lines) {
+		return nil, p.errorf("unexpected indentation")
+	}
+	o, ok := v.(*qs.Object)
+	if !ok {
+		return nil, errors.New("expected a mapping")
+	}
+	return o, nil
+}
+
+type yamlLine struct {
+	n      int
+	indent int
+	text   string
+}
+
+type yamlParser struct {
+	lines []yamlLine
+	i     int
+}
+
+func (p *yamlParser) errorf(format string, a ...any) error {
+	n := 0
+	if p.i < len(p.lines) {
+		n = p.lines[p.i].n
+	} else if len(p.lines) > 0 {
+		n = p.lines[len(p.lines)-1].n
+	}
+	return fmt.Errorf("line %d: %s", n, fmt.Sprintf(format, a...))
+}
+
+func isItem(text string) bool {
+	return text == "-" || strings.HasPrefix(text, "- ")
+}
+
+// block reads the mapping or sequence starting at the current line, whose
+// lines are indented by indent.
+func (p *yamlParser) block(indent int) (any, error) {
+	if isItem(p.lines[p.i].text) {
+		return p.sequence(indent)
+	}
+	return p.mapping(indent, qs.NewObject())
+}
+
+func (p *yamlParser) sequence(indent int) (any, error) {
+	items := []any{}
+	for p.i < len(p.lines) && p.lines[p.i].indent == indent && isItem(p.lines[p.i].text) {
+		line := p.lines[p.i]
+		item := strings.TrimLeft(strings.TrimPrefix(line.text, "-"), " ")
+		text := stripComment(item)
+		switch {
+		case text == "":
+			p.i++
+			v, err := p.nested(indent)
+			if err != nil {
+				return nil, err
+			}
+			items = append(items, v)
+		case isItem(text) || hasKey(text):
+			// "- - item" and "- key: value" start a block indented past
+			// the dash
+			content := indent + len(line.text) - len(item)
+			p.lines[p.i] = yamlLine{line.n, content, item}
+			v, err := p.block(content)
+			if err != nil {
+				return nil, err
+			}
+			items = append(items, v)
+		default:
+			v, err := scalar(text)
+			if err != nil {
+				return nil, p.errorf("%v", err)
+			}
+			p.i++
+			items = append(items, v)
+		}
+	}
+	return items, nil
+}
+
+func (p *yamlParser) mapping(indent int, o *qs.Object) (any, error) {
+	for p.i < len(p.lines) && p.lines[p.i].indent == indent && !isItem(p.lines[p.i].text) {
+		key, value, ok, err := splitPair(p.lines[p.i].text)
+		if err != nil || !ok {
+			return nil, p.errorf("expected key: value")
+		}
+		if _, dup := o.Get(key); dup {
+			return nil, p.errorf("duplicate key %q", key)
+		}
+		p.i++
+		var v any
+		if value = stripComment(value); value != "" {
+			if v, err = scalar(value); err != nil {
+				return nil, p.errorf("%v", err)
+			}
+		} else if p.i < len(p.lines) && p.lines[p.i].indent == indent && isItem(p.lines[p.i].text) {
+			// a sequence may be indented as far as its key
+			if v, err = p.sequence(indent); err != nil {
+				return nil, err
+			}
+		} else if v, err = p.nested(indent); err != nil {
+			return nil, err
+		}
+		o.Set(key, v)
+	}
+	return o, nil
+}
+
+// nested reads the block indented past indent, or nil when there is none.
+func (p *yamlParser) nested(indent int) (any, error) {
+	if p.i >= len(p.lines) || p.lines[p.i].indent <= indent {
+		return nil, nil
+	}
+	return p.block(p.lines[p.i].indent)
+}
+
+// hasKey reports whether text starts with a "key:" outside quotes.
+func hasKey(text string) bool {
+	_, _, ok, err := splitPair(text)
+	return ok && err == nil
+}
+
+// splitPair splits "key: value" on the first separator outside quotes.
+func splitPair(text string) (key, value string, ok bool, err error) {
+	if text == "" || text[0] == '[' || text[0] == '{' {
+		return "", "", false, nil
+	}
+	end := 0
+	if text[0] == '"' || text[0] == '\'' {
+		if end, err = quoteEnd(text); err != nil {
+			return "", "", false, err
+		}
+	}
+	for i := end; i < len(text); i++ {
+		if text[i] == ':' && (i+1 == len(text) || text[i+1] == ' ') {
+			k, err := scalar(text[:i])
+			if err != nil {
+				return "", "", false, err
+			}
+			ks, _ := k.(string)
+			return ks, strings.TrimSpace(text[i+1:]), true, nil
+		}
+	}
+	return "", "", false, nil
+}
+
+// quoteEnd returns the index just past the quoted scalar text starts with.
+func quoteEnd(text string) (int, error) {
+	q := text[0]
+	for i := 1; i < len(text); i++ {
+		switch {
+		case q == '"' && text[i] == '\\':
+			i++
+		case text[i] == q && q == '\'' && i+1 < len(text) && text[i+1] == '\'':
+			i++
+		case text[i] == q:
+			return i + 1, nil
+		}
+	}
+	return 0, errors.New("unterminated quoted string")
+}
+
+// stripComment removes a comment, a "#" at the start or after a space,
+// that is outside quotes.
+func stripComment(text string) string {
+	var quote byte
+	for i := 0; i < len(text); i++ {
+		switch c := text[i]; {
+		case quote == '"' && c == '\\':
+			i++
+		case quote != 0:
+			if c == quote {
+				quote = 0
+			}
+		case c == '"' || c == '\'':
+			quote = c
+		case c == '#' && (i == 0 || text[i-1] == ' ' || text[i-1] == '\t'):
+			return strings.TrimSpace(text[:i])
+		}
+	}
+	return text
+}
+
+// scalar reads a plain or quoted scalar, or a flow sequence or mapping of
+// scalars.
+func scalar(text string) (any, error) {
+	text = stripComment(text)
+	if text == "" {
+		return nil, nil
+	}
+	switch text[0] {
+	case '"', '\'':
+		end, err := quoteEnd(text)
+		if err != nil {
+			return nil, err
+		}
+		if rest := text[end:]; rest != "" {
+			return nil, fmt.Errorf("unexpected %q after quoted string", rest)
+		}
+		if text[0] == '\'' {
+			return strings.ReplaceAll(text[1:end-1], "''", "'"), nil
+		}
+		return strconv.Unquote(text)
+	case '[', '{':
+		return flow(text)
+	}
+	if text == "null" || text == "~" {
+		return nil, nil
+	}
+	return text, nil
+}
+
+// flow reads a single line flow sequence or mapping of scalars.
+func flow(text string) (any, error) {
+	open, close := text[0], byte(']')
+	if open == '{' {
+		close = '}'
+	}
+	if text[len(text)-1] != close {
+		return nil, fmt.Errorf("unterminated %c", open)
+	}
+	items, err := splitFlow(text[1 : len(text)-1])
+	if err != nil {
+		return nil, err
+	}
+
+	if open == '[' {
+		seq := []any{}
+		for _, item := range items {
+			v, err := scalar(item)
+			if err != nil {
+				return nil, err
+			}
+			seq = append(seq, v)
+		}
+		return seq, nil
+	}
+	o := qs.NewObject()
+	for _, item := range items {
+		k, v, ok, err := splitPair(item)
+		if err != nil || !ok {
+			return nil, fmt.Errorf("expected key: value in %q", item)
+		}
+		value, err := scalar(v)
+		if err != nil {
+			return nil, err
+		}
+		o.Set(k, value)
+	}
+	return o, nil
+}
+
+// splitFlow splits the body of a flow collection on the commas outside
+// quotes. A trailing comma is allowed.
+func splitFlow(body string) ([]string, error) {
+	var items []string
+	var quote byte
+	start := 0
+	for i := 0; i < len(body); i++ {
+		switch c := body[i]; {
+		case quote == '"' && c == '\\':
+			i++
+		case quote != 0:
+			if c == quote {
+				quote = 0
+			}
+		case c == '"' || c == '\'':
+			quote = c
+		case c == '[' || c == '{':
+			return nil, errors.New("nested flow collections are not supported")
+		case c == ',':
+			items = append(items, strings.TrimSpace(body[start:i]))
+			start = i + 1
+		}
+	}
+	if quote != 0 {
+		return nil, errors.New("unterminated quoted string")
+	}
+	if last := strings.TrimSpace(body[start:]); last != "" {
+		items = append(items, last)
+	}
+	for _, item := range items {
+		if item == "" {
+			return nil, errors.New("empty flow item")
+		}
+	}
+	return items, nil
+}
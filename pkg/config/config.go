@@ -0,0 +1,256 @@
+// Package config reads the durl configuration file, a subset of TOML
+// holding default option values and named presets:
+//
+//	color = "always"
+//	jobs = 4
+//
+//	[preset.logclean]
+//	strip-userinfo = true
+//	canonical-query = true
+//	param = ["utm_*", "gclid"]
+//
+// Keys are the long names of command line options. Values are strings,
+// integers, booleans or single line arrays of them. Tables other than
+// [preset.NAME], dotted keys, inline tables and multi-line strings are not
+// supported.
+package config
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Setting is an option and its values. Arrays give several values.
+type Setting struct {
+	Key    string
+	Values []string
+	Line   int
+}
+
+// Config is a parsed configuration file.
+type Config struct {
+	Defaults []Setting
+	Presets  map[string][]Setting
+}
+
+// PresetNames returns the names of the presets in order.
+func (c *Config) PresetNames() []string {
+	names := make([]string, 0, len(c.Presets))
+	for name := range c.Presets {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Path returns the location of the configuration file,
+// $XDG_CONFIG_HOME/durl/config.toml, or ~/.config/durl/config.toml when
+// XDG_CONFIG_HOME is not set.
+func Path() (string, error) {
+	dir := os.Getenv("XDG_CONFIG_HOME")
+	if dir == "" {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return "", err
+		}
+		dir = filepath.Join(home, ".config")
+	}
+	return filepath.Join(dir, "durl", "config.toml"), nil
+}
+
+// Load reads the configuration file at path. A missing file is an empty
+// configuration.
+func Load(path string) (*Config, error) {
+	f, err := os.Open(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return &Config{Presets: map[string][]Setting{}}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return Parse(f)
+}
+
+// Parse reads a configuration.
+func Parse(r io.Reader) (*Config, error) {
+	c := &Config{Presets: map[string][]Setting{}}
+	preset := "" // the table being read, "" for the defaults
+	seen := map[string]bool{}
+	scanner := bufio.NewScanner(r)
+	for n := 1; scanner.Scan(); n++ {
+		line := strings.TrimSpace(stripComment(scanner.Text()))
+		if line == "" {
+			continue
+		}
+		if strings.HasPrefix(line, "[") {
+			name, err := presetName(line)
+			if err != nil {
+				return nil, fmt.Errorf("line %d: %w", n, err)
+			}
+			if _, ok := c.Presets[name]; ok {
+				return nil, fmt.Errorf("line %d: duplicate preset %q", n, name)
+			}
+			c.Presets[name] = []Setting{}
+			preset = name
+			seen = map[string]bool{}
+			continue
+		}
+		key, value, ok := strings.Cut(line, "=")
+		if !ok {
+			return nil, fmt.Errorf("line %d: expected key = value", n)
+		}
+		key = strings.TrimSpace(key)
+		if !bareKey(key) {
+			return nil, fmt.Errorf("line %d: invalid key %q", n, key)
+		}
+		if seen[key] {
+			return nil, fmt.Errorf("line %d: duplicate key %q", n, key)
+		}
+		seen[key] = true
+		values, err := parseValue(strings.TrimSpace(value))
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %s: %w", n, key, err)
+		}
+		s := Setting{key, values, n}
+		if preset == "" {
+			c.Defaults = append(c.Defaults, s)
+		} else {
+			c.Presets[preset] = append(c.Presets[preset], s)
+		}
+	}
+	return c, scanner.Err()
+}
+
+// presetName reads a [preset.NAME] table header.
+func presetName(line string) (string, error) {
+	if !strings.HasSuffix(line, "]") || strings.HasPrefix(line, "[[") {
+		return "", fmt.Errorf("invalid table %s", line)
+	}
+	header := strings.TrimSpace(line[1 : len(line)-1])
+	name, ok := strings.CutPrefix(header, "preset.")
+	if !ok {
+		return "", fmt.Errorf("unknown table [%s], expected [preset.NAME]", header)
+	}
+	if strings.HasPrefix(name, `"`) {
+		unquoted, err := strconv.Unquote(name)
+		if err != nil {
+			return "", fmt.Errorf("invalid preset name %s", name)
+		}
+		return unquoted, nil
+	}
+	if !bareKey(name) {
+		return "", fmt.Errorf("invalid preset name %q", name)
+	}
+	return name, nil
+}
+
+func bareKey(s string) bool {
+	if s == "" {
+		return false
+	}
+	for _, c := range s {
+		if !('a' <= c && c <= 'z' || 'A' <= c && c <= 'Z' || '0' <= c && c <= '9' || c == '-' || c == '_') {
+			return false
+		}
+	}
+	return true
+}
+
+// stripComment removes a # comment that is outside strings.
+func stripComment(line string) string {
+	var quote byte
+	for i := 0; i < len(line); i++ {
+		switch c := line[i]; {
+		case quote == '"' && c == '\\':
+			i++
+		case quote != 0:
+			if c == quote {
+				quote = 0
+			}
+		case c == '"' || c == '\'':
+			quote = c
+		case c == '#':
+			return line[:i]
+		}
+	}
+	return line
+}
+
+// parseValue reads a scalar, or an array of scalars, as strings.
+func parseValue(s string) ([]string, error) {
+	if !strings.HasPrefix(s, "[") {
+		v, rest, err := scalar(s)
+		if err != nil {
+			return nil, err
+		}
+		if rest != "" {
+			return nil, fmt.Errorf("unexpected %q", rest)
+		}
+		return []string{v}, nil
+	}
+	values := []string{}
+	s = strings.TrimSpace(s[1:])
+	for !strings.HasPrefix(s, "]") {
+		v, rest, err := scalar(s)
+		if err != nil {
+			return nil, err
+		}
+		values = append(values, v)
+		s = strings.TrimSpace(rest)
+		if strings.HasPrefix(s, ",") {
+			s = strings.TrimSpace(s[1:])
+		} else if !strings.HasPrefix(s, "]") {
+			return nil, errors.New("expected , or ] in array")
+		}
+	}
+	if rest := strings.TrimSpace(s[1:]); rest != "" {
+		return nil, fmt.Errorf("unexpected %q", rest)
+	}
+	return values, nil
+}
+
+// scalar reads the string, integer or boolean s starts with and returns
+// the text after it.
+func scalar(s string) (value, rest string, err error) {
+	switch {
+	case s == "":
+		return "", "", errors.New("missing value")
+	case s[0] == '"':
+		for i := 1; i < len(s); i++ {
+			switch s[i] {
+			case '\\':
+				i++
+			case '"':
+				v, err := strconv.Unquote(s[:i+1])
+				return v, strings.TrimSpace(s[i+1:]), err
+			}
+		}
+		return "", "", errors.New("unterminated string")
+	case s[0] == '\'':
+		end := strings.IndexByte(s[1:], '\'')
+		if end < 0 {
+			return "", "", errors.New("unterminated string")
+		}
+		return s[1 : end+1], strings.TrimSpace(s[end+2:]), nil
+	}
+	end := strings.IndexAny(s, ",] \t")
+	if end < 0 {
+		end = len(s)
+	}
+	word := s[:end]
+	if word != "true" && word != "false" {
+		if _, err := strconv.ParseInt(strings.ReplaceAll(word, "_", ""), 10, 64); err != nil {
+			return "", "", fmt.Errorf("invalid value %q, strings must be quoted", word)
+		}
+		word = strings.ReplaceAll(word, "_", "")
+	}
+	return word, strings.TrimSpace(s[end:]), nil
+}
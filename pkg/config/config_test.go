@@ -0,0 +1,76 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestParse(t *testing.T) {
+	const in = `# defaults
+color = "always" # trailing comment
+jobs = 1_000
+
+[preset.logclean]
+strip-userinfo = true
+set-scheme = 'https'
+param = ["utm_*", "a#b", 'c',]
+
+[preset."with space"]
+`
+	c, err := Parse(strings.NewReader(in))
+	require.NoError(t, err)
+	require.Equal(t, []Setting{
+		{"color", []string{"always"}, 2},
+		{"jobs", []string{"1000"}, 3},
+	}, c.Defaults)
+	require.Equal(t, []Setting{
+		{"strip-userinfo", []string{"true"}, 6},
+		{"set-scheme", []string{"https"}, 7},
+		{"param", []string{"utm_*", "a#b", "c"}, 8},
+	}, c.Presets["logclean"])
+	require.Equal(t, []string{"logclean", "with space"}, c.PresetNames())
+}
+
+func TestParseErrors(t *testing.T) {
+	for _, in := range []string{
+		"color = always",
+		"color",
+		"a.b = 1",
+		"a = 1\na = 2",
+		`a = "x`,
+		"a = [1, 2",
+		"a = 1 2",
+		"[server]",
+		"[preset.a]\n[preset.a]",
+		"[[preset.a]]",
+	} {
+		t.Run(in, func(t *testing.T) {
+			_, err := Parse(strings.NewReader(in))
+			require.Error(t, err)
+		})
+	}
+}
+
+func TestLoad(t *testing.T) {
+	dir := t.TempDir()
+	c, err := Load(filepath.Join(dir, "missing.toml"))
+	require.NoError(t, err)
+	require.Len(t, c.Defaults, 0)
+
+	path := filepath.Join(dir, "config.toml")
+	require.NoError(t, os.WriteFile(path, []byte("[preset.a]\nstrip-query = true\n"), 0o644))
+	c, err = Load(path)
+	require.NoError(t, err)
+	require.Len(t, c.Presets["a"], 1)
+}
+
+func TestPath(t *testing.T) {
+	t.Setenv("XDG_CONFIG_HOME", "/etc/xdg")
+	path, err := Path()
+	require.NoError(t, err)
+	require.Equal(t, filepath.Join("/etc/xdg", "durl", "config.toml"), path)
+}
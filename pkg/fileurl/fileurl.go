@@ -0,0 +1,157 @@
+// Package fileurl converts between file: URLs and filesystem paths using
+// POSIX or Windows rules. It is pure string manipulation and never touches
+// the filesystem, so Windows paths can be handled on any platform.
+package fileurl
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"path"
+	"strings"
+
+	"github.com/dcilke/durl/pkg/urlax"
+)
+
+// Style selects the path syntax.
+type Style int
+
+const (
+	// POSIX paths are "/" separated and rooted at "/".
+	POSIX Style = iota
+	// Windows paths use "\", drive letters (C:\) and UNC shares (\\server\share).
+	Windows
+)
+
+// ToPath converts a file: URL to a path. A host other than "localhost"
+// becomes a UNC path in Windows style and is an error in POSIX style.
+func ToPath(rawURL string, style Style) (string, error) {
+	u, err := urlax.Parse(rawURL)
+	if err != nil {
+		return "", err
+	}
+	if u.Scheme != "file" {
+		return "", fmt.Errorf("scheme %q is not file", u.Scheme)
+	}
+	p := u.Path
+	if u.Opaque != "" {
+		// file:C:/foo or file:relative
+		p = u.Opaque
+		if up, err := url.PathUnescape(p); err == nil {
+			p = up
+		}
+	}
+	host := u.Host
+	if strings.EqualFold(host, "localhost") {
+		host = ""
+	}
+
+	if style == POSIX {
+		if host != "" {
+			return "", fmt.Errorf("cannot convert non-local host %q to a POSIX path", host)
+		}
+		if p == "" {
+			p = "/"
+		}
+		return p, nil
+	}
+
+	if host == "" && strings.HasPrefix(p, "//") {
+		// file:////server/share is a UNC path with an empty authority
+		host, p, _ = strings.Cut(strings.TrimLeft(p, "/"), "/")
+		p = "/" + p
+	}
+	if host != "" {
+		return `\\` + host + strings.ReplaceAll(p, "/", `\`), nil
+	}
+	p = strings.TrimPrefix(p, "/")
+	if isDrive(p) {
+		// accept the legacy C| form of a drive letter
+		p = p[:1] + ":" + p[2:]
+		if len(p) == 2 {
+			p += "/"
+		}
+	} else {
+		p = "/" + p
+	}
+	return strings.ReplaceAll(p, "/", `\`), nil
+}
+
+// FromPath converts a path to a file: URL. Relative paths are resolved
+// against cwd, which must be absolute in the same style. Spaces, "%", "?",
+// "#" and non-ASCII characters are percent-encoded.
+func FromPath(p string, style Style, cwd string) (string, error) {
+	if p == "" {
+		return "", errors.New("empty path")
+	}
+	u := &url.URL{Scheme: "file"}
+
+	if style == POSIX {
+		if !strings.HasPrefix(p, "/") {
+			if !strings.HasPrefix(cwd, "/") {
+				return "", fmt.Errorf("cannot resolve relative path %q without an absolute working directory", p)
+			}
+			p = cwd + "/" + p
+		}
+		u.Path = clean(p)
+		return u.String(), nil
+	}
+
+	p = strings.ReplaceAll(p, `\`, "/")
+	cwd = strings.ReplaceAll(cwd, `\`, "/")
+	switch {
+	case strings.HasPrefix(p, "//"):
+		// \\server\share\dir
+		host, rest, _ := strings.Cut(p[2:], "/")
+		if host == "" {
+			return "", fmt.Errorf("UNC path %q has no server", p)
+		}
+		u.Host = host
+		u.Path = clean("/" + rest)
+		return u.String(), nil
+	case isDrive(p) && (len(p) == 2 || p[2] == '/'):
+		// C:\dir
+	case isDrive(p):
+		// C:dir is relative to the working directory on drive C
+		if !isDrive(cwd) || !strings.EqualFold(cwd[:1], p[:1]) {
+			return "", fmt.Errorf("cannot resolve drive relative path %q against %q", p, cwd)
+		}
+		p = cwd + "/" + p[2:]
+	case strings.HasPrefix(p, "/"):
+		// \dir is relative to the drive of the working directory
+		if !isDrive(cwd) {
+			return "", fmt.Errorf("cannot resolve rooted path %q without a working directory drive", p)
+		}
+		p = cwd[:2] + p
+	default:
+		switch {
+		case isDrive(cwd):
+			p = cwd + "/" + p
+		case strings.HasPrefix(cwd, "//"):
+			return FromPath(cwd+"/"+p, style, "")
+		default:
+			return "", fmt.Errorf("cannot resolve relative path %q without an absolute working directory", p)
+		}
+	}
+	u.Path = "/" + strings.ToUpper(p[:1]) + ":" + clean("/"+p[2:])
+	return u.String(), nil
+}
+
+// isDrive reports whether p starts with a drive letter such as "C:" or
+// the legacy "C|".
+func isDrive(p string) bool {
+	if len(p) < 2 || (p[1] != ':' && p[1] != '|') {
+		return false
+	}
+	c := p[0]
+	return 'a' <= c && c <= 'z' || 'A' <= c && c <= 'Z'
+}
+
+// clean resolves "." and ".." segments, keeping a trailing slash.
+func clean(p string) string {
+	c := path.Clean(p)
+	if strings.HasSuffix(p, "/") && c != "/" {
+		c += "/"
+	}
+	return c
+}
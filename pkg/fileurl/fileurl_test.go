@@ -0,0 +1,95 @@
+package fileurl
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestToPath(t *testing.T) {
+	for _, tt := range []struct {
+		in    string
+		style Style
+		out   string
+	}{
+		{"file:///home/adg/rabbits", POSIX, "/home/adg/rabbits"},
+		{"file://localhost/etc/hosts", POSIX, "/etc/hosts"},
+		{"file:///tmp/my%20file%E2%82%AC.txt", POSIX, "/tmp/my file€.txt"},
+		{"file:///C:/FooBar/Baz.txt", POSIX, "/C:/FooBar/Baz.txt"},
+		{"file:///C:/FooBar/Baz.txt", Windows, `C:\FooBar\Baz.txt`},
+		{"file:///c|/Foo%20Bar", Windows, `c:\Foo Bar`},
+		{"file:///C:", Windows, `C:\`},
+		{"file://server/share/dir/f.txt", Windows, `\\server\share\dir\f.txt`},
+		{"file:////server/share/f.txt", Windows, `\\server\share\f.txt`},
+		{"file:///home/adg", Windows, `\home\adg`},
+	} {
+		t.Run(tt.in, func(t *testing.T) {
+			p, err := ToPath(tt.in, tt.style)
+			require.NoError(t, err)
+			require.Equal(t, tt.out, p)
+		})
+	}
+}
+
+func TestToPathErrors(t *testing.T) {
+	for _, in := range []string{
+		"http://example.com/x",
+		"file://server/share",
+	} {
+		t.Run(in, func(t *testing.T) {
+			_, err := ToPath(in, POSIX)
+			require.Error(t, err)
+		})
+	}
+}
+
+func TestFromPath(t *testing.T) {
+	for _, tt := range []struct {
+		in    string
+		style Style
+		cwd   string
+		out   string
+	}{
+		{"/home/adg/rabbits", POSIX, "", "file:///home/adg/rabbits"},
+		{"/tmp/my file€?#%.txt", POSIX, "", "file:///tmp/my%20file%E2%82%AC%3F%23%25.txt"},
+		{"docs/../notes.txt", POSIX, "/home/adg", "file:///home/adg/notes.txt"},
+		{"./dir/", POSIX, "/srv", "file:///srv/dir/"},
+		{`C:\FooBar\Baz.txt`, Windows, "", "file:///C:/FooBar/Baz.txt"},
+		{`c:/Foo Bar/..\Baz.txt`, Windows, "", "file:///C:/Baz.txt"},
+		{`\\server\share\dir\f.txt`, Windows, "", "file://server/share/dir/f.txt"},
+		{`sub\f.txt`, Windows, `D:\work`, "file:///D:/work/sub/f.txt"},
+		{`\Windows\win.ini`, Windows, `D:\work`, "file:///D:/Windows/win.ini"},
+		{`d:f.txt`, Windows, `D:\work`, "file:///D:/work/f.txt"},
+		{`f.txt`, Windows, `\\server\share\dir`, "file://server/share/dir/f.txt"},
+	} {
+		t.Run(tt.in, func(t *testing.T) {
+			u, err := FromPath(tt.in, tt.style, tt.cwd)
+			require.NoError(t, err)
+			require.Equal(t, tt.out, u)
+
+			// the URL must convert back to the absolute path
+			_, err = ToPath(u, tt.style)
+			require.NoError(t, err)
+		})
+	}
+}
+
+func TestFromPathErrors(t *testing.T) {
+	for _, tt := range []struct {
+		in    string
+		style Style
+		cwd   string
+	}{
+		{"", POSIX, "/"},
+		{"relative", POSIX, ""},
+		{"relative", Windows, ""},
+		{`\rooted`, Windows, "/posix"},
+		{`e:f.txt`, Windows, `D:\work`},
+		{`\\`, Windows, ""},
+	} {
+		t.Run(tt.in, func(t *testing.T) {
+			_, err := FromPath(tt.in, tt.style, tt.cwd)
+			require.Error(t, err)
+		})
+	}
+}
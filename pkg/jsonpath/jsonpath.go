@@ -0,0 +1,217 @@
+// Package jsonpath rewrites string values at a path inside JSON documents
+// while leaving the rest of the document as written.
+//
+// Paths use a small jq-like syntax:
+//
+//	.request.url        object members
+//	.urls[0]            array index
+//	.urls[] .urls[*]    every array element
+//	.*                  every object member
+//	.["a.b"]            member with special characters
+package jsonpath
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Step is a single path element.
+type Step struct {
+	Key      string // object member, when Index is -1 and not Wildcard
+	Index    int    // array index, or -1
+	Wildcard bool   // every member or element
+	Array    bool   // step applies to arrays
+}
+
+// Path is a parsed path.
+type Path []Step
+
+// Parse parses a path expression.
+func Parse(s string) (Path, error) {
+	var p Path
+	i := 0
+	for i < len(s) {
+		switch s[i] {
+		case '.':
+			i++
+			if i >= len(s) || s[i] == '.' {
+				if len(p) == 0 && i >= len(s) {
+					// "." is the document itself
+					return p, nil
+				}
+				return nil, fmt.Errorf("empty member name at offset %d", i)
+			}
+			if s[i] == '[' {
+				continue
+			}
+			if s[i] == '*' {
+				p = append(p, Step{Index: -1, Wildcard: true})
+				i++
+				continue
+			}
+			start := i
+			for i < len(s) && s[i] != '.' && s[i] != '[' && s[i] != ']' {
+				i++
+			}
+			p = append(p, Step{Key: s[start:i], Index: -1})
+		case '[':
+			end := strings.IndexByte(s[i:], ']')
+			if s[i+1:min(i+2, len(s))] == `"` {
+				// quoted member name may contain "]"
+				key, rest, err := quoted(s[i+1:])
+				if err != nil {
+					return nil, err
+				}
+				if !strings.HasPrefix(rest, "]") {
+					return nil, fmt.Errorf("missing \"]\" after %q", key)
+				}
+				p = append(p, Step{Key: key, Index: -1})
+				i = len(s) - len(rest) + 1
+				continue
+			}
+			if end < 0 {
+				return nil, fmt.Errorf("missing \"]\" at offset %d", i)
+			}
+			inner := s[i+1 : i+end]
+			i += end + 1
+			if inner == "" || inner == "*" {
+				p = append(p, Step{Index: -1, Wildcard: true, Array: true})
+				continue
+			}
+			n, err := strconv.Atoi(inner)
+			if err != nil || n < 0 {
+				return nil, fmt.Errorf("invalid array index %q", inner)
+			}
+			p = append(p, Step{Index: n, Array: true})
+		default:
+			if i == 0 {
+				// allow the leading "." to be omitted
+				s = "." + s
+				continue
+			}
+			return nil, fmt.Errorf("unexpected %q at offset %d", s[i], i)
+		}
+	}
+	return p, nil
+}
+
+func quoted(s string) (string, string, error) {
+	for i := 1; i < len(s); i++ {
+		switch s[i] {
+		case '\\':
+			i++
+		case '"':
+			key, err := strconv.Unquote(s[:i+1])
+			return key, s[i+1:], err
+		}
+	}
+	return "", "", fmt.Errorf("unterminated member name %s", s)
+}
+
+// Rewrite calls fn for each string value at p in doc and returns the
+// document with those values replaced. Values that are missing or are not
+// strings are skipped. The new values are spliced in, so everything else
+// keeps its original bytes.
+func Rewrite(doc []byte, p Path, fn func(string) (string, error)) ([]byte, error) {
+	doc = bytes.TrimSpace(doc)
+	var edits []edit
+	if err := rewrite(doc, 0, p, fn, &edits); err != nil {
+		return nil, err
+	}
+	if len(edits) == 0 {
+		return doc, nil
+	}
+	var buf bytes.Buffer
+	last := 0
+	for _, e := range edits {
+		buf.Write(doc[last:e.start])
+		buf.Write(e.value)
+		last = e.end
+	}
+	buf.Write(doc[last:])
+	return buf.Bytes(), nil
+}
+
+// edit replaces the bytes from start to end of a document with value.
+type edit struct {
+	start, end int
+	value      []byte
+}
+
+// rewrite records the edits for the values at p in raw, which starts at
+// offset off of the document. Edits are recorded in document order.
+func rewrite(raw []byte, off int, p Path, fn func(string) (string, error), edits *[]edit) error {
+	trimmed := bytes.TrimLeft(raw, " \t\r\n")
+	off += len(raw) - len(trimmed)
+	raw = bytes.TrimRight(trimmed, " \t\r\n")
+	if len(raw) == 0 {
+		return nil
+	}
+	if len(p) == 0 {
+		if raw[0] != '"' {
+			return nil
+		}
+		var s string
+		if err := json.Unmarshal(raw, &s); err != nil {
+			return err
+		}
+		s, err := fn(s)
+		if err != nil {
+			return err
+		}
+		b, err := marshal(s)
+		if err != nil {
+			return err
+		}
+		*edits = append(*edits, edit{off, off + len(raw), b})
+		return nil
+	}
+
+	step, rest := p[0], p[1:]
+	isObject := raw[0] == '{' && (!step.Array || step.Wildcard)
+	isArray := raw[0] == '[' && (step.Array || step.Wildcard)
+	if !isObject && !isArray {
+		return nil
+	}
+	dec := json.NewDecoder(bytes.NewReader(raw))
+	if _, err := dec.Token(); err != nil {
+		return err
+	}
+	for i := 0; dec.More(); i++ {
+		match := step.Wildcard || i == step.Index
+		if isObject {
+			t, err := dec.Token()
+			if err != nil {
+				return err
+			}
+			match = step.Wildcard || t.(string) == step.Key
+		}
+		var v json.RawMessage
+		if err := dec.Decode(&v); err != nil {
+			return err
+		}
+		if match {
+			// the value ends where the decoder stopped reading
+			end := int(dec.InputOffset())
+			if err := rewrite(v, off+end-len(v), rest, fn, edits); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// marshal encodes s without escaping HTML characters, which are common in
+// URLs.
+func marshal(s string) ([]byte, error) {
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	enc.SetEscapeHTML(false)
+	if err := enc.Encode(s); err != nil {
+		return nil, err
+	}
+	return bytes.TrimRight(buf.Bytes(), "\n"), nil
+}
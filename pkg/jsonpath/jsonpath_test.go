@@ -0,0 +1,68 @@
+package jsonpath
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestParse(t *testing.T) {
+	for in, out := range map[string]Path{
+		".":                  nil,
+		".request.url":       {{Key: "request", Index: -1}, {Key: "url", Index: -1}},
+		"request.url":        {{Key: "request", Index: -1}, {Key: "url", Index: -1}},
+		".urls[0]":           {{Key: "urls", Index: -1}, {Index: 0, Array: true}},
+		".urls[].href":       {{Key: "urls", Index: -1}, {Index: -1, Wildcard: true, Array: true}, {Key: "href", Index: -1}},
+		".urls[*]":           {{Key: "urls", Index: -1}, {Index: -1, Wildcard: true, Array: true}},
+		".*.url":             {{Index: -1, Wildcard: true}, {Key: "url", Index: -1}},
+		`.["a.b]"].c`:        {{Key: "a.b]", Index: -1}, {Key: "c", Index: -1}},
+		`.[0][1]`:            {{Index: 0, Array: true}, {Index: 1, Array: true}},
+		`.headers["x-url"]`:  {{Key: "headers", Index: -1}, {Key: "x-url", Index: -1}},
+		`.headers.["x-url"]`: {{Key: "headers", Index: -1}, {Key: "x-url", Index: -1}},
+	} {
+		t.Run(in, func(t *testing.T) {
+			p, err := Parse(in)
+			require.NoError(t, err)
+			require.Equal(t, out, p)
+		})
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	for _, in := range []string{"..a", ".a[", ".a[-1]", ".a[x]", `.["a`, `.["a"x]`, ".a]"} {
+		t.Run(in, func(t *testing.T) {
+			_, err := Parse(in)
+			require.Error(t, err)
+		})
+	}
+}
+
+func TestRewrite(t *testing.T) {
+	upper := func(s string) (string, error) { return strings.ToUpper(s), nil }
+	for _, tt := range []struct {
+		path, in, out string
+	}{
+		{".request.url", `{"a": 1, "request": {"url": "http://x/<a>", "n": [1, 2]}, "z": "url"}`, `{"a": 1, "request": {"url": "HTTP://X/<A>", "n": [1, 2]}, "z": "url"}`},
+		{".missing", `{"a": "b"}`, `{"a": "b"}`},
+		{".a", `{"a": 5}`, `{"a": 5}`},
+		{".a[]", `{"a": ["x", 1, "y"]}`, `{"a": ["X", 1, "Y"]}`},
+		{".a[1]", `{"a": ["x", "y"]}`, `{"a": ["x", "Y"]}`},
+		{".*", `{"a": "x", "b": "y"}`, `{"a": "X", "b": "Y"}`},
+		{".[].u", `[{"u": "x"}, {"v": "y"}]`, `[{"u": "X"}, {"v": "y"}]`},
+		{".", `"x"`, `"X"`},
+		{".b", `{"aA": "x", "b": "é"}`, `{"aA": "x", "b": "É"}`},
+		{".req.url", `{"req": {"url": "u",  "n": 1.50}, "other" : [1, 2]}`, `{"req": {"url": "U",  "n": 1.50}, "other" : [1, 2]}`},
+		{".k\u00e9y", `{"k\u00e9y" : "x", "k\/": "y"}`, `{"k\u00e9y" : "X", "k\/": "y"}`},
+		{".a[]", "{\n  \"a\": [\n    \"x\" ,\n    \"y\"\n  ]\n}", "{\n  \"a\": [\n    \"X\" ,\n    \"Y\"\n  ]\n}"},
+		{".a", `  {"a":"x"}  `, `{"a":"X"}`},
+	} {
+		t.Run(tt.path+" "+tt.in, func(t *testing.T) {
+			p, err := Parse(tt.path)
+			require.NoError(t, err)
+			out, err := Rewrite([]byte(tt.in), p, upper)
+			require.NoError(t, err)
+			require.Equal(t, tt.out, string(out))
+		})
+	}
+}
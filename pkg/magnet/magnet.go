@@ -0,0 +1,151 @@
+// Package magnet parses magnet URIs into their exact topics, trackers and
+// other well known parameters.
+package magnet
+
+import (
+	"encoding/base32"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/dcilke/durl/pkg/urlax"
+)
+
+// Topic is a single exact topic (xt) of a magnet URI.
+type Topic struct {
+	URN       string `json:"urn"`
+	Type      string `json:"type"`
+	Algorithm string `json:"algorithm,omitempty"`
+	Hash      string `json:"hash"`
+}
+
+// Param is a magnet parameter without a dedicated field.
+type Param struct {
+	Key   string `json:"key"`
+	Value string `json:"value"`
+}
+
+// Magnet is a parsed magnet URI. Repeated and numbered (xt.1, tr.2, ...)
+// parameters are kept in input order.
+type Magnet struct {
+	Topics      []Topic  `json:"topics"`
+	DisplayName string   `json:"displayName,omitempty"`
+	Length      *int64   `json:"length,omitempty"`
+	Trackers    []string `json:"trackers,omitempty"`
+	WebSeeds    []string `json:"webSeeds,omitempty"`
+	Sources     []string `json:"sources,omitempty"`
+	Keywords    []string `json:"keywords,omitempty"`
+	Params      []Param  `json:"params,omitempty"`
+}
+
+// Parse parses a magnet URI.
+func Parse(s string) (*Magnet, error) {
+	u, err := urlax.Parse(s)
+	if err != nil {
+		return nil, err
+	}
+	if u.Scheme != "magnet" {
+		return nil, fmt.Errorf("scheme %q is not magnet", u.Scheme)
+	}
+
+	m := &Magnet{}
+	for q := u.RawQuery; q != ""; {
+		var kv string
+		kv, q, _ = strings.Cut(q, "&")
+		if kv == "" {
+			continue
+		}
+		k, v, _ := strings.Cut(kv, "=")
+		if uv, err := url.QueryUnescape(v); err == nil {
+			v = uv
+		}
+		// numbered parameters such as xt.1 share the meaning of xt
+		key, _, _ := strings.Cut(k, ".")
+		switch key {
+		case "xt":
+			t, err := ParseTopic(v)
+			if err != nil {
+				return nil, err
+			}
+			m.Topics = append(m.Topics, t)
+		case "dn":
+			m.DisplayName = v
+		case "xl":
+			n, err := strconv.ParseInt(v, 10, 64)
+			if err != nil || n < 0 {
+				return nil, fmt.Errorf("invalid exact length %q", v)
+			}
+			m.Length = &n
+		case "tr":
+			m.Trackers = append(m.Trackers, v)
+		case "ws":
+			m.WebSeeds = append(m.WebSeeds, v)
+		case "as", "xs":
+			m.Sources = append(m.Sources, v)
+		case "kt":
+			m.Keywords = append(m.Keywords, strings.Fields(v)...)
+		default:
+			m.Params = append(m.Params, Param{k, v})
+		}
+	}
+	if len(m.Topics) == 0 {
+		return nil, errors.New("magnet URI has no exact topic (xt)")
+	}
+	return m, nil
+}
+
+// ParseTopic parses an exact topic URN. BitTorrent info hashes are
+// normalized to lowercase hex, whether given as hex or base32.
+func ParseTopic(urn string) (Topic, error) {
+	t := Topic{URN: urn}
+	rest, ok := cutPrefixFold(urn, "urn:")
+	if !ok {
+		return t, fmt.Errorf("exact topic %q is not a URN", urn)
+	}
+	t.Type, t.Hash, ok = strings.Cut(rest, ":")
+	if !ok {
+		return t, fmt.Errorf("exact topic %q has no hash", urn)
+	}
+	t.Type = strings.ToLower(t.Type)
+
+	switch t.Type {
+	case "btih":
+		t.Algorithm = "sha1"
+		switch len(t.Hash) {
+		case 40:
+			b, err := hex.DecodeString(t.Hash)
+			if err != nil {
+				return t, fmt.Errorf("invalid btih hex hash %q", t.Hash)
+			}
+			t.Hash = hex.EncodeToString(b)
+		case 32:
+			b, err := base32.StdEncoding.DecodeString(strings.ToUpper(t.Hash))
+			if err != nil {
+				return t, fmt.Errorf("invalid btih base32 hash %q", t.Hash)
+			}
+			t.Hash = hex.EncodeToString(b)
+		default:
+			return t, fmt.Errorf("btih hash %q must be 40 hex or 32 base32 characters", t.Hash)
+		}
+	case "btmh":
+		b, err := hex.DecodeString(t.Hash)
+		if err != nil || len(b) < 2 || int(b[1]) != len(b)-2 {
+			return t, fmt.Errorf("invalid btmh multihash %q", t.Hash)
+		}
+		t.Hash = hex.EncodeToString(b)
+		if b[0] == 0x12 {
+			t.Algorithm = "sha2-256"
+		}
+	}
+	return t, nil
+}
+
+func cutPrefixFold(s, prefix string) (string, bool) {
+	if len(s) < len(prefix) || !strings.EqualFold(s[:len(prefix)], prefix) {
+		return s, false
+	}
+	return s[len(prefix):], true
+}
@@ -0,0 +1,56 @@
+package magnet
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestParse(t *testing.T) {
+	length := int64(1024)
+	for in, out := range map[string]*Magnet{
+		"magnet:?xt=urn:btih:c12fe1c06bba254a9dc9f519b335aa7c1367a88a&dn": {
+			Topics: []Topic{{URN: "urn:btih:c12fe1c06bba254a9dc9f519b335aa7c1367a88a", Type: "btih", Algorithm: "sha1", Hash: "c12fe1c06bba254a9dc9f519b335aa7c1367a88a"}},
+		},
+		"magnet:?xt=urn:btih:C12FE1C06BBA254A9DC9F519B335AA7C1367A88A&dn=My+File%21&xl=1024&tr=udp%3A%2F%2Ftracker.example%3A80&tr=http://t2/announce&ws=https://seed/file": {
+			Topics:      []Topic{{URN: "urn:btih:C12FE1C06BBA254A9DC9F519B335AA7C1367A88A", Type: "btih", Algorithm: "sha1", Hash: "c12fe1c06bba254a9dc9f519b335aa7c1367a88a"}},
+			DisplayName: "My File!",
+			Length:      &length,
+			Trackers:    []string{"udp://tracker.example:80", "http://t2/announce"},
+			WebSeeds:    []string{"https://seed/file"},
+		},
+		"magnet:?xt.1=urn:btih:YEX6DQDLXISUVHOJ6UM3GNNKPQJWPKEK&xt.2=urn:btmh:1220caf1e1c30e81cb361b9ee167c4aa64228a7fa4fa9f6105232b28ad099f3a302e&x.pe=1.2.3.4:5": {
+			Topics: []Topic{
+				{URN: "urn:btih:YEX6DQDLXISUVHOJ6UM3GNNKPQJWPKEK", Type: "btih", Algorithm: "sha1", Hash: "c12fe1c06bba254a9dc9f519b335aa7c1367a88a"},
+				{URN: "urn:btmh:1220caf1e1c30e81cb361b9ee167c4aa64228a7fa4fa9f6105232b28ad099f3a302e", Type: "btmh", Algorithm: "sha2-256", Hash: "1220caf1e1c30e81cb361b9ee167c4aa64228a7fa4fa9f6105232b28ad099f3a302e"},
+			},
+			Params: []Param{{"x.pe", "1.2.3.4:5"}},
+		},
+		"magnet:?xt=urn:sha1:ZNLDP7XBMXWSWVCTHNGVQFIXVHGXLPNT&kt=ubuntu+iso": {
+			Topics:   []Topic{{URN: "urn:sha1:ZNLDP7XBMXWSWVCTHNGVQFIXVHGXLPNT", Type: "sha1", Hash: "ZNLDP7XBMXWSWVCTHNGVQFIXVHGXLPNT"}},
+			Keywords: []string{"ubuntu", "iso"},
+		},
+	} {
+		t.Run(in, func(t *testing.T) {
+			m, err := Parse(in)
+			require.NoError(t, err)
+			require.Equal(t, out, m)
+		})
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	for _, in := range []string{
+		"http://example.com/?xt=urn:btih:c12fe1c06bba254a9dc9f519b335aa7c1367a88a",
+		"magnet:?dn=nothing",
+		"magnet:?xt=urn:btih:abc",
+		"magnet:?xt=urn:btih:zz2fe1c06bba254a9dc9f519b335aa7c1367a88a",
+		"magnet:?xt=urn:btmh:1220ab",
+		"magnet:?xt=urn:btih:c12fe1c06bba254a9dc9f519b335aa7c1367a88a&xl=-1",
+	} {
+		t.Run(in, func(t *testing.T) {
+			_, err := Parse(in)
+			require.Error(t, err)
+		})
+	}
+}
@@ -0,0 +1,436 @@
+// Package qs converts between nested, bracket style query strings such as
+// filter[status]=open&filter[tags][]=a and JSON, the way Rails, PHP and
+// the qs JavaScript library read and write them.
+package qs
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/dcilke/durl/pkg/urlax"
+)
+
+// Object is a JSON object that keeps the order of its keys. Values are
+// strings, *Object, []any or nil.
+type Object struct {
+	Keys   []string
+	Values map[string]any
+}
+
+// NewObject returns an empty object.
+func NewObject() *Object {
+	return &Object{Values: map[string]any{}}
+}
+
+// Get returns the value of a key.
+func (o *Object) Get(key string) (any, bool) {
+	v, ok := o.Values[key]
+	return v, ok
+}
+
+// Set sets the value of a key, appending the key when it is new.
+func (o *Object) Set(key string, value any) {
+	if _, ok := o.Values[key]; !ok {
+		o.Keys = append(o.Keys, key)
+	}
+	o.Values[key] = value
+}
+
+// MarshalJSON writes the object with its keys in order.
+func (o *Object) MarshalJSON() ([]byte, error) {
+	var buf bytes.Buffer
+	buf.WriteByte('{')
+	for i, k := range o.Keys {
+		if i > 0 {
+			buf.WriteByte(',')
+		}
+		if err := encode(&buf, k); err != nil {
+			return nil, err
+		}
+		buf.WriteByte(':')
+		if err := encode(&buf, o.Values[k]); err != nil {
+			return nil, err
+		}
+	}
+	buf.WriteByte('}')
+	return buf.Bytes(), nil
+}
+
+// encode writes v as JSON without escaping HTML characters.
+func encode(buf *bytes.Buffer, v any) error {
+	enc := json.NewEncoder(buf)
+	enc.SetEscapeHTML(false)
+	if err := enc.Encode(v); err != nil {
+		return err
+	}
+	buf.Truncate(buf.Len() - 1) // drop the newline Encode adds
+	return nil
+}
+
+// ParseOptions configures Parse.
+type ParseOptions struct {
+	// Comma splits values on "," into arrays, reading the comma array
+	// format.
+	Comma bool
+}
+
+// Parse reads a raw query, without the "?", into an object. Keys with
+// brackets nest: a[b]=c sets key b of object a, a[]=c appends to array a
+// and a[0]=c sets its first element. Repeated keys become arrays.
+func Parse(rawQuery string, opts ParseOptions) (*Object, error) {
+	root := NewObject()
+	for _, p := range urlax.ParseQuery(rawQuery).Params {
+		if p.RawKey == "" {
+			continue
+		}
+		var value any = p.Value()
+		if opts.Comma && strings.Contains(p.RawValue, ",") {
+			var values []any
+			for _, v := range strings.Split(p.Value(), ",") {
+				values = append(values, v)
+			}
+			value = values
+		}
+		if _, err := insert(root, splitKey(p.Key()), value); err != nil {
+			return nil, fmt.Errorf("parameter %q: %w", p.Key(), err)
+		}
+	}
+	return finish(root).(*Object), nil
+}
+
+// splitKey splits a[b][] into a, b and "". Text after an unclosed bracket
+// is kept as part of the last segment.
+func splitKey(key string) []string {
+	i := strings.IndexByte(key, '[')
+	if i <= 0 {
+		return []string{key}
+	}
+	segments := []string{key[:i]}
+	rest := key[i:]
+	for strings.HasPrefix(rest, "[") {
+		j := strings.IndexByte(rest, ']')
+		if j < 0 {
+			break
+		}
+		segments = append(segments, rest[1:j])
+		rest = rest[j+1:]
+	}
+	if rest != "" {
+		segments[len(segments)-1] += rest
+	}
+	return segments
+}
+
+// array is an array being parsed. Indices may arrive in any order and
+// with gaps; finish sorts and compacts them.
+type array struct {
+	values map[int]any
+	next   int
+}
+
+func (a *array) set(i int, v any) {
+	a.values[i] = v
+	if i >= a.next {
+		a.next = i + 1
+	}
+}
+
+var errConflict = errors.New("conflicts with an earlier parameter")
+
+// insert sets value at the path of segments below container, creating
+// objects and arrays as needed, and returns the updated container.
+func insert(container any, segments []string, value any) (any, error) {
+	seg := segments[0]
+	index, isIndex := arrayIndex(seg)
+	if container == nil {
+		if isIndex {
+			container = &array{values: map[int]any{}}
+		} else {
+			container = NewObject()
+		}
+	}
+
+	switch c := container.(type) {
+	case *Object:
+		child, exists := c.Get(seg)
+		if len(segments) == 1 {
+			c.Set(seg, merge(child, exists, value))
+			return c, nil
+		}
+		if exists {
+			child = nest(child)
+		}
+		v, err := insert(child, segments[1:], value)
+		if err != nil {
+			return nil, err
+		}
+		c.Set(seg, v)
+		return c, nil
+
+	case *array:
+		if !isIndex {
+			// a[]=1&a[b]=2 turns the array into an object keyed by index
+			o := NewObject()
+			for _, i := range c.indices() {
+				o.Set(strconv.Itoa(i), c.values[i])
+			}
+			return insert(o, segments, value)
+		}
+		if index < 0 {
+			index = c.next
+		}
+		child, exists := c.values[index]
+		if len(segments) == 1 {
+			c.set(index, merge(child, exists, value))
+			return c, nil
+		}
+		v, err := insert(child, segments[1:], value)
+		if err != nil {
+			return nil, err
+		}
+		c.set(index, v)
+		return c, nil
+	}
+	return nil, errConflict
+}
+
+// nest turns plain values into an array so that nested keys can be added
+// to them: a=1&a[b]=2 and a=1&a[]=2 keep the plain value under an index,
+// as qs does.
+func nest(v any) any {
+	var values []any
+	switch v := v.(type) {
+	case string:
+		values = []any{v}
+	case []any:
+		values = v
+	default:
+		return v
+	}
+	a := &array{values: map[int]any{}}
+	for _, e := range values {
+		a.set(a.next, e)
+	}
+	return a
+}
+
+func (a *array) indices() []int {
+	indices := make([]int, 0, len(a.values))
+	for i := range a.values {
+		indices = append(indices, i)
+	}
+	sort.Ints(indices)
+	return indices
+}
+
+// finish replaces the arrays being parsed with compact slices.
+func finish(v any) any {
+	switch v := v.(type) {
+	case *Object:
+		for _, k := range v.Keys {
+			v.Values[k] = finish(v.Values[k])
+		}
+	case *array:
+		values := make([]any, 0, len(v.values))
+		for _, i := range v.indices() {
+			values = append(values, finish(v.values[i]))
+		}
+		return values
+	}
+	return v
+}
+
+// arrayIndex reports whether a segment addresses an array: "" appends and
+// is reported as -1, digits give an index.
+func arrayIndex(seg string) (int, bool) {
+	if seg == "" {
+		return -1, true
+	}
+	n, err := strconv.Atoi(seg)
+	if err != nil || n < 0 || strconv.Itoa(n) != seg {
+		return 0, false
+	}
+	return n, true
+}
+
+// merge combines a repeated plain value with what is already there. An
+// array or object built from bracket keys gets the value under its next
+// index, as qs does.
+func merge(existing any, exists bool, value any) any {
+	if !exists {
+		return value
+	}
+	values, ok := value.([]any)
+	if !ok {
+		values = []any{value}
+	}
+	switch e := existing.(type) {
+	case []any:
+		return append(e, values...)
+	case *array:
+		for _, v := range values {
+			e.set(e.next, v)
+		}
+		return e
+	case *Object:
+		next := 0
+		for _, k := range e.Keys {
+			if i, ok := arrayIndex(k); ok && i >= next {
+				next = i + 1
+			}
+		}
+		for _, v := range values {
+			e.Set(strconv.Itoa(next), v)
+			next++
+		}
+		return e
+	}
+	return append([]any{existing}, values...)
+}
+
+// ArrayFormat is the way Stringify writes arrays.
+type ArrayFormat int
+
+const (
+	Brackets ArrayFormat = iota // a[]=1&a[]=2
+	Indices                     // a[0]=1&a[1]=2
+	Repeat                      // a=1&a=2
+	Comma                       // a=1,2
+)
+
+// ArrayFormats maps the names of the array formats to their values.
+var ArrayFormats = map[string]ArrayFormat{
+	"brackets": Brackets,
+	"indices":  Indices,
+	"repeat":   Repeat,
+	"comma":    Comma,
+}
+
+// Stringify writes an object as a nested query string. Arrays that hold
+// objects or arrays always use indices so they can be read back. Null
+// values are written as a key without "=", and empty objects and arrays
+// are left out.
+func Stringify(o *Object, format ArrayFormat) string {
+	q := &urlax.Query{}
+	for _, k := range o.Keys {
+		stringify(q, escapeKey(k), o.Values[k], format)
+	}
+	return q.String()
+}
+
+func stringify(q *urlax.Query, key string, v any, format ArrayFormat) {
+	switch v := v.(type) {
+	case nil:
+		q.Params = append(q.Params, urlax.Param{RawKey: key})
+	case string:
+		q.Params = append(q.Params, urlax.Param{RawKey: key, RawValue: urlax.Escape(v, urlax.QueryComponent), HasValue: true})
+	case *Object:
+		for _, k := range v.Keys {
+			stringify(q, key+"["+escapeKey(k)+"]", v.Values[k], format)
+		}
+	case []any:
+		f := format
+		for _, e := range v {
+			if _, ok := e.(string); !ok {
+				f = Indices
+				break
+			}
+		}
+		if f == Comma && len(v) > 0 {
+			values := make([]string, len(v))
+			for i, e := range v {
+				values[i] = urlax.Escape(e.(string), urlax.QueryComponent)
+			}
+			q.Params = append(q.Params, urlax.Param{RawKey: key, RawValue: strings.Join(values, ","), HasValue: true})
+			return
+		}
+		for i, e := range v {
+			switch f {
+			case Brackets:
+				stringify(q, key+"[]", e, f)
+			case Indices:
+				stringify(q, key+"["+strconv.Itoa(i)+"]", e, format)
+			case Repeat:
+				stringify(q, key, e, f)
+			}
+		}
+	}
+}
+
+// escapeKey escapes a key segment, leaving brackets readable.
+func escapeKey(k string) string {
+	k = urlax.Escape(k, urlax.QueryComponent)
+	return strings.NewReplacer("%5B", "[", "%5D", "]").Replace(k)
+}
+
+// Decode reads a JSON object, keeping the order of keys. Numbers and
+// booleans become strings.
+func Decode(data []byte) (*Object, error) {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	v, err := decodeValue(dec)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := dec.Token(); err != io.EOF {
+		return nil, errors.New("unexpected data after the JSON object")
+	}
+	o, ok := v.(*Object)
+	if !ok {
+		return nil, errors.New("expected a JSON object")
+	}
+	return o, nil
+}
+
+func decodeValue(dec *json.Decoder) (any, error) {
+	t, err := dec.Token()
+	if err != nil {
+		return nil, err
+	}
+	switch t := t.(type) {
+	case json.Delim:
+		switch t {
+		case '{':
+			o := NewObject()
+			for dec.More() {
+				k, err := dec.Token()
+				if err != nil {
+					return nil, err
+				}
+				v, err := decodeValue(dec)
+				if err != nil {
+					return nil, err
+				}
+				o.Set(k.(string), v)
+			}
+			_, err := dec.Token()
+			return o, err
+		case '[':
+			a := []any{}
+			for dec.More() {
+				v, err := decodeValue(dec)
+				if err != nil {
+					return nil, err
+				}
+				a = append(a, v)
+			}
+			_, err := dec.Token()
+			return a, err
+		}
+	case json.Number:
+		return t.String(), nil
+	case bool:
+		return strconv.FormatBool(t), nil
+	case string:
+		return t, nil
+	case nil:
+		return nil, nil
+	}
+	return nil, fmt.Errorf("unexpected %v", t)
+}
@@ -0,0 +1,90 @@
+package qs
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestParse(t *testing.T) {
+	for in, out := range map[string]string{
+		"a=1&b=2": `{"a":"1","b":"2"}`,
+		"a=1&a=2": `{"a":["1","2"]}`,
+		"filter[status]=open&filter[tags][]=a&filter[tags][]=b": `{"filter":{"status":"open","tags":["a","b"]}}`,
+		"a[1]=x&a[0]=y":                                `{"a":["y","x"]}`,
+		"a[0][b]=1&a[0][c]=2&a[1][b]=3":                `{"a":[{"b":"1","c":"2"},{"b":"3"}]}`,
+		"user[name]=J%C3%BCrgen+X&user[roles][]=admin": `{"user":{"name":"Jürgen X","roles":["admin"]}}`,
+		"a%5Bb%5D=1":                                   `{"a":{"b":"1"}}`,
+		"a[b=1":                                        `{"a[b":"1"}`,
+		"a[b]x=1":                                      `{"a":{"bx":"1"}}`,
+		"flag&x=":                                      `{"flag":"","x":""}`,
+		"a[]=1&a[b]=2":                                 `{"a":{"0":"1","b":"2"}}`,
+		"a[]=1&a=2":                                    `{"a":["1","2"]}`,
+		"a=1&a[]=2":                                    `{"a":["1","2"]}`,
+		"a[b]=1&a=2":                                   `{"a":{"b":"1","0":"2"}}`,
+		"a=1&a[b]=2":                                   `{"a":{"0":"1","b":"2"}}`,
+		"a=1&a=2&a[b]=3":                               `{"a":{"0":"1","1":"2","b":"3"}}`,
+		"a[1]=x&a[b]=y&a=z":                            `{"a":{"1":"x","b":"y","2":"z"}}`,
+		"a[5]=x&a[]=y&a[2]=z":                          `{"a":["z","x","y"]}`,
+		"":                                             `{}`,
+	} {
+		t.Run(in, func(t *testing.T) {
+			o, err := Parse(in, ParseOptions{})
+			require.NoError(t, err)
+			b, err := o.MarshalJSON()
+			require.NoError(t, err)
+			require.Equal(t, out, string(b))
+		})
+	}
+}
+
+func TestParseComma(t *testing.T) {
+	o, err := Parse("a=1,2&b=3&c=x%2Cy", ParseOptions{Comma: true})
+	require.NoError(t, err)
+	b, err := o.MarshalJSON()
+	require.NoError(t, err)
+	require.Equal(t, `{"a":["1","2"],"b":"3","c":"x,y"}`, string(b))
+}
+
+func TestParseConflict(t *testing.T) {
+	_, err := Parse("a[b]=1&a[b][c]=2&a[b][]=3", ParseOptions{})
+	require.NoError(t, err)
+	_, err = Parse("a[]=1&a[0][b]=2", ParseOptions{})
+	require.Error(t, err)
+}
+
+func TestStringify(t *testing.T) {
+	const in = `{"filter":{"status":"open","tags":["a","b c"]},"page":2,"sort":null,"items":[{"id":1}],"empty":[],"q":"x&y"}`
+	for format, out := range map[ArrayFormat]string{
+		Brackets: "filter[status]=open&filter[tags][]=a&filter[tags][]=b+c&page=2&sort&items[0][id]=1&q=x%26y",
+		Indices:  "filter[status]=open&filter[tags][0]=a&filter[tags][1]=b+c&page=2&sort&items[0][id]=1&q=x%26y",
+		Repeat:   "filter[status]=open&filter[tags]=a&filter[tags]=b+c&page=2&sort&items[0][id]=1&q=x%26y",
+		Comma:    "filter[status]=open&filter[tags]=a,b+c&page=2&sort&items[0][id]=1&q=x%26y",
+	} {
+		t.Run(out, func(t *testing.T) {
+			o, err := Decode([]byte(in))
+			require.NoError(t, err)
+			require.Equal(t, out, Stringify(o, format))
+		})
+	}
+}
+
+func TestRoundTrip(t *testing.T) {
+	const in = `{"filter":{"status":"open","tags":["a","b"]},"items":[{"id":"1"},{"id":"2"}],"k":"a=b&c"}`
+	o, err := Decode([]byte(in))
+	require.NoError(t, err)
+	back, err := Parse(Stringify(o, Brackets), ParseOptions{})
+	require.NoError(t, err)
+	b, err := back.MarshalJSON()
+	require.NoError(t, err)
+	require.Equal(t, in, string(b))
+}
+
+func TestDecodeErrors(t *testing.T) {
+	for _, in := range []string{`[1]`, `"a"`, `{"a":1} {}`, `{"a":`} {
+		t.Run(in, func(t *testing.T) {
+			_, err := Decode([]byte(in))
+			require.Error(t, err)
+		})
+	}
+}
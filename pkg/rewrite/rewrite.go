@@ -0,0 +1,215 @@
+// Package rewrite rewrites URLs with a table of rules, such as the host
+// renames of a domain migration.
+package rewrite
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"regexp"
+	"strings"
+
+	"github.com/dcilke/durl/pkg/urlax"
+)
+
+// Kind is the way a rule matches URLs.
+type Kind int
+
+const (
+	Host   Kind = iota // the hostname equals the pattern
+	Prefix             // the hostname and a leading part of the path equal the pattern
+	Regexp             // the pattern, prefixed with "~", matches the URL text
+)
+
+// Rule maps URLs matching Pattern to Replacement.
+//
+//	old.example.com           new.example.com:8443
+//	old.example.com/api/v1/   api.example.com/v1/
+//	~^http://([a-z]+)\.old\.  https://$1.new.
+//
+// Host rules replace the hostname, and the port when the replacement has
+// one. Prefix rules also replace the matched part of the path. Regexp
+// rules replace the first match in the URL, expanding $1 style groups.
+type Rule struct {
+	Kind        Kind
+	Pattern     string
+	Replacement string
+	Line        int // line in the mapping file, zero when not read from one
+
+	host, path string // Host and Prefix patterns
+	re         *regexp.Regexp
+}
+
+// NewRule parses a pattern and its replacement.
+func NewRule(pattern, replacement string) (*Rule, error) {
+	r := &Rule{Pattern: pattern, Replacement: replacement}
+	switch {
+	case strings.HasPrefix(pattern, "~"):
+		re, err := regexp.Compile(pattern[1:])
+		if err != nil {
+			return nil, err
+		}
+		r.Kind, r.re = Regexp, re
+		return r, nil
+	case strings.Contains(pattern, "/"):
+		r.Kind = Prefix
+		r.host, r.path, _ = strings.Cut(pattern, "/")
+		r.path = "/" + r.path
+		if _, _, ok := strings.Cut(replacement, "/"); !ok {
+			return nil, errors.New("replacement of a prefix rule must have a path")
+		}
+	default:
+		r.Kind, r.host = Host, pattern
+	}
+	if r.host == "" {
+		return nil, errors.New("empty host")
+	}
+	r.host = strings.ToLower(r.host)
+	return r, nil
+}
+
+// Parse reads rules from a mapping file with one tab separated pattern and
+// replacement per line. Blank lines and lines starting with "#" are
+// ignored.
+func Parse(r io.Reader) ([]*Rule, error) {
+	var rules []*Rule
+	s := bufio.NewScanner(r)
+	for n := 1; s.Scan(); n++ {
+		line := strings.TrimRight(s.Text(), "\r")
+		if strings.TrimSpace(line) == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		pattern, replacement, ok := strings.Cut(line, "\t")
+		if !ok {
+			return nil, fmt.Errorf("line %d: expected a pattern and a replacement separated by a tab", n)
+		}
+		rule, err := NewRule(pattern, replacement)
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", n, err)
+		}
+		rule.Line = n
+		rules = append(rules, rule)
+	}
+	return rules, s.Err()
+}
+
+// match reports how much of u the rule matches, or -1 when it does not.
+func (r *Rule) match(raw string, u *urlax.URL) int {
+	switch r.Kind {
+	case Regexp:
+		if m := r.re.FindStringIndex(raw); m != nil {
+			return m[1] - m[0]
+		}
+		return -1
+	case Host:
+		if strings.ToLower(u.Host.Value()) == r.host {
+			return len(r.host)
+		}
+		return -1
+	}
+	path := u.Path.Value()
+	if strings.ToLower(u.Host.Value()) != r.host || !strings.HasPrefix(path, r.path) {
+		return -1
+	}
+	// only match whole path segments
+	if len(path) > len(r.path) && !strings.HasSuffix(r.path, "/") && path[len(r.path)] != '/' {
+		return -1
+	}
+	return len(r.host) + len(r.path)
+}
+
+// apply rewrites a URL the rule matches.
+func (r *Rule) apply(raw string, u *urlax.URL) (string, error) {
+	if r.Kind == Regexp {
+		m := r.re.FindStringSubmatchIndex(raw)
+		out := r.re.ExpandString(nil, r.Replacement, raw, m)
+		return raw[:m[0]] + string(out) + raw[m[1]:], nil
+	}
+
+	host, path, _ := strings.Cut(r.Replacement, "/")
+	if hostname, port, err := net.SplitHostPort(host); err == nil {
+		if err := u.SetPort(port); err != nil {
+			return "", err
+		}
+		host = hostname
+	}
+	if err := u.SetHost(host); err != nil {
+		return "", err
+	}
+	if r.Kind == Prefix {
+		// keep the rest of the path as escaped, only the prefix changes
+		rest := u.Path.Raw[rawPrefix(u.Path.Raw, len(r.path)):]
+		prefix := urlax.Escape("/"+path, urlax.PathComponent)
+		// exactly one slash joins the replacement to the rest of the path
+		switch slash := strings.HasSuffix(prefix, "/"); {
+		case rest == "":
+		case slash && strings.HasPrefix(rest, "/"):
+			rest = rest[1:]
+		case !slash && !strings.HasPrefix(rest, "/"):
+			rest = "/" + rest
+		}
+		if err := u.SetRawPath(prefix + rest); err != nil {
+			return "", err
+		}
+	}
+	return u.String(), nil
+}
+
+// rawPrefix returns the length of the escaped text of raw that decodes to
+// its first n bytes.
+func rawPrefix(raw string, n int) int {
+	i := 0
+	for ; n > 0 && i < len(raw); n-- {
+		if raw[i] == '%' && i+2 < len(raw) && ishex(raw[i+1]) && ishex(raw[i+2]) {
+			i += 3
+		} else {
+			i++
+		}
+	}
+	return i
+}
+
+func ishex(c byte) bool {
+	return '0' <= c && c <= '9' || 'a' <= c && c <= 'f' || 'A' <= c && c <= 'F'
+}
+
+// Mode selects the rule used when several match.
+type Mode int
+
+const (
+	First   Mode = iota // the first matching rule in order
+	Longest             // the rule matching the most of the URL, the first of equals
+)
+
+// Rewriter applies a list of rules.
+type Rewriter struct {
+	Rules []*Rule
+	Mode  Mode
+}
+
+// Rewrite applies the selected rule to rawURL. When no rule matches, the
+// URL is returned unchanged with a nil rule.
+func (rw *Rewriter) Rewrite(rawURL string) (string, *Rule, error) {
+	u, err := urlax.ParseLossless(rawURL)
+	if err != nil {
+		return "", nil, err
+	}
+	var best *Rule
+	bestLen := -1
+	for _, r := range rw.Rules {
+		n := r.match(rawURL, u)
+		if n > bestLen {
+			best, bestLen = r, n
+			if rw.Mode == First {
+				break
+			}
+		}
+	}
+	if best == nil {
+		return rawURL, nil, nil
+	}
+	out, err := best.apply(rawURL, u)
+	return out, best, err
+}
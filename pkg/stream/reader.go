@@ -0,0 +1,95 @@
+package stream
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+)
+
+// bom is the UTF-8 encoding of U+FEFF, which some editors put at the start
+// of a file.
+const bom = "\xef\xbb\xbf"
+
+// LineTooLongError reports a record longer than Options.MaxLineLength. The
+// record is skipped and reading continues with the next one.
+type LineTooLongError struct {
+	Record int // 1-based record number
+	Max    int
+}
+
+func (e *LineTooLongError) Error() string {
+	return fmt.Sprintf("record %d exceeds the maximum length of %d bytes", e.Record, e.Max)
+}
+
+// Reader splits input into records. Records end at the delimiter or at the
+// end of input. With the newline delimiter a trailing carriage return is
+// removed, and a UTF-8 byte order mark is removed from the first record.
+type Reader struct {
+	r      *bufio.Reader
+	delim  byte
+	max    int
+	record int
+}
+
+// NewReader returns a Reader framing r as described by opts.
+func NewReader(r io.Reader, opts Options) *Reader {
+	return &Reader{
+		r:     bufio.NewReaderSize(r, 64<<10),
+		delim: opts.delimiter(),
+		max:   opts.MaxLineLength,
+	}
+}
+
+// Next returns the next record. It returns io.EOF when the input is
+// exhausted and a *LineTooLongError for records over the length limit,
+// after which Next may be called again.
+func (r *Reader) Next() (string, error) {
+	var buf []byte
+	tooLong := false
+	for {
+		chunk, err := r.r.ReadSlice(r.delim)
+		if !tooLong {
+			buf = append(buf, chunk...)
+			// leave room for the delimiter, a carriage return and a BOM,
+			// which are trimmed below
+			if r.max > 0 && len(buf) > r.max+len(bom)+2 {
+				tooLong, buf = true, nil
+			}
+		}
+		if errors.Is(err, bufio.ErrBufferFull) {
+			continue
+		}
+		if err == io.EOF && len(buf) == 0 && !tooLong {
+			return "", io.EOF
+		}
+		if err != nil && err != io.EOF {
+			return "", err
+		}
+		break
+	}
+
+	r.record++
+	buf = bytes.TrimSuffix(buf, []byte{r.delim})
+	if r.delim == '\n' {
+		buf = bytes.TrimSuffix(buf, []byte{'\r'})
+	}
+	if r.record == 1 {
+		buf = bytes.TrimPrefix(buf, []byte(bom))
+	}
+	if tooLong || (r.max > 0 && len(buf) > r.max) {
+		return "", &LineTooLongError{Record: r.record, Max: r.max}
+	}
+	return string(buf), nil
+}
+
+// SkipBOM returns a reader for r without a leading UTF-8 byte order mark,
+// for input that is not framed by a Reader such as CSV or JSON.
+func SkipBOM(r io.Reader) io.Reader {
+	br := bufio.NewReader(r)
+	if b, err := br.Peek(len(bom)); err == nil && string(b) == bom {
+		br.Discard(len(bom))
+	}
+	return br
+}
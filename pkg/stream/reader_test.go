@@ -0,0 +1,90 @@
+package stream
+
+import (
+	"bytes"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func records(t *testing.T, in string, opts Options) ([]string, []error) {
+	r := NewReader(strings.NewReader(in), opts)
+	var lines []string
+	var errs []error
+	for {
+		line, err := r.Next()
+		if err == io.EOF {
+			return lines, errs
+		}
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		lines = append(lines, line)
+	}
+}
+
+func TestReader(t *testing.T) {
+	for _, tt := range []struct {
+		name string
+		in   string
+		opts Options
+		out  []string
+	}{
+		{"lf", "a\nb\n", Options{}, []string{"a", "b"}},
+		{"no trailing newline", "a\nb", Options{}, []string{"a", "b"}},
+		{"crlf", "a\r\nb\r\n", Options{}, []string{"a", "b"}},
+		{"bom", bom + "a\n" + bom + "b\n", Options{}, []string{"a", bom + "b"}},
+		{"empty lines", "\n\na\n", Options{}, []string{"", "", "a"}},
+		{"null", "a b\nc\x00d\r\x00", Options{Null: true}, []string{"a b\nc", "d\r"}},
+		{"long", strings.Repeat("x", 1<<20) + "\ny\n", Options{}, []string{strings.Repeat("x", 1<<20), "y"}},
+		{"at max", "abc\r\nabcd\n", Options{MaxLineLength: 3}, []string{"abc"}},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			lines, _ := records(t, tt.in, tt.opts)
+			require.Equal(t, tt.out, lines)
+		})
+	}
+}
+
+func TestReaderTooLong(t *testing.T) {
+	in := "short\n" + strings.Repeat("x", 200<<10) + "\nafter\n"
+	lines, errs := records(t, in, Options{MaxLineLength: 100})
+	require.Equal(t, []string{"short", "after"}, lines)
+	require.Len(t, errs, 1)
+	require.Equal(t, &LineTooLongError{Record: 2, Max: 100}, errs[0])
+}
+
+func TestRunFraming(t *testing.T) {
+	echo := func(line string) (string, bool, error) { return "<" + line + ">", true, nil }
+	for _, jobs := range []int{1, 4} {
+		var out bytes.Buffer
+		var errs []error
+		_, err := Run(strings.NewReader(bom+"a\r\nbb\r\nc"), &out, Options{Jobs: jobs, MaxLineLength: 1, OnError: func(line string, err error) {
+			errs = append(errs, err)
+		}}, echo)
+		require.NoError(t, err)
+		require.Equal(t, "<a>\n<c>\n", out.String())
+		require.Len(t, errs, 1)
+
+		out.Reset()
+		_, err = Run(strings.NewReader("a\nb\x00c\x00"), &out, Options{Jobs: jobs, Null: true}, echo)
+		require.NoError(t, err)
+		require.Equal(t, "<a\nb>\x00<c>\x00", out.String())
+	}
+}
+
+func TestSkipBOM(t *testing.T) {
+	for in, out := range map[string]string{
+		bom + "a,b": "a,b",
+		"a" + bom:   "a" + bom,
+		"\xef\xbb":  "\xef\xbb",
+		"":          "",
+	} {
+		b, err := io.ReadAll(SkipBOM(strings.NewReader(in)))
+		require.NoError(t, err)
+		require.Equal(t, out, string(b))
+	}
+}
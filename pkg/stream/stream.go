@@ -0,0 +1,281 @@
+// Package stream runs a line oriented transformation over an input stream
+// with a pool of workers and buffered output.
+package stream
+
+import (
+	"bufio"
+	"errors"
+	"io"
+	"sync"
+)
+
+// batchSize is the number of lines handed to a worker at once. Batching
+// keeps the per-line channel overhead negligible next to the work itself.
+const batchSize = 256
+
+// Func transforms a single line. When ok is false nothing is written for
+// the line.
+type Func func(line string) (out string, ok bool, err error)
+
+// Options configures Run.
+type Options struct {
+	// Jobs is the number of workers. Values below 2 process lines inline.
+	Jobs int
+	// Unordered writes results as soon as they are ready instead of in
+	// input order.
+	Unordered bool
+	// Null separates input and output records with NUL instead of newline.
+	Null bool
+	// MaxLineLength skips and reports input records longer than this many
+	// bytes. Zero means no limit.
+	MaxLineLength int
+	// FailFast stops at the first failed record. Records before it are
+	// still written.
+	FailFast bool
+	// Placeholder, when set, is written in place of each failed record so
+	// the output stays aligned with the input.
+	Placeholder *string
+	// OnError is called for each line whose Func returned an error and for
+	// each record over MaxLineLength, after the output so far is flushed.
+	// It is never called concurrently.
+	OnError func(line string, err error)
+}
+
+// Stats summarizes a run.
+type Stats struct {
+	Records int  // records read and handled
+	Failed  int  // records that failed
+	Stopped bool // the run stopped early because of FailFast
+}
+
+func (o Options) delimiter() byte {
+	if o.Null {
+		return 0
+	}
+	return '\n'
+}
+
+type result struct {
+	out string
+	ok  bool
+	err error
+}
+
+type batch struct {
+	lines   []string
+	results []result
+	done    chan struct{}
+}
+
+// source yields records until io.EOF.
+type source interface {
+	Next() (string, error)
+}
+
+// Run reads records from r, applies fn to each and writes the results to
+// w, each followed by the record delimiter. The returned error reports
+// reading and writing failures; failed records are only counted in Stats.
+func Run(r io.Reader, w io.Writer, opts Options, fn Func) (Stats, error) {
+	return run(NewReader(r, opts), w, opts, fn)
+}
+
+// RunSlice is like Run but takes its records from a slice, such as the
+// command line arguments.
+func RunSlice(records []string, w io.Writer, opts Options, fn Func) (Stats, error) {
+	return run(&sliceSource{records: records}, w, opts, fn)
+}
+
+type sliceSource struct {
+	records []string
+}
+
+func (s *sliceSource) Next() (string, error) {
+	if len(s.records) == 0 {
+		return "", io.EOF
+	}
+	record := s.records[0]
+	s.records = s.records[1:]
+	return record, nil
+}
+
+// writer emits results in the order it is given them and keeps the stats.
+type writer struct {
+	w     *bufio.Writer
+	opts  Options
+	stats Stats
+	err   error
+}
+
+// emit writes a single result. It reports false once the run should stop.
+func (w *writer) emit(line string, res result) bool {
+	if w.err != nil || w.stats.Stopped {
+		return false
+	}
+	w.stats.Records++
+	if res.err != nil {
+		w.stats.Failed++
+		if w.opts.OnError != nil {
+			// flush first so errors appear in order with the output
+			w.err = w.w.Flush()
+			w.opts.OnError(line, res.err)
+		}
+		if w.err != nil {
+			return false
+		}
+		if w.opts.FailFast {
+			w.stats.Stopped = true
+			return false
+		}
+		if w.opts.Placeholder == nil {
+			return true
+		}
+		res.out, res.ok = *w.opts.Placeholder, true
+	}
+	if !res.ok {
+		return true
+	}
+	if _, w.err = w.w.WriteString(res.out); w.err == nil {
+		w.err = w.w.WriteByte(w.opts.delimiter())
+	}
+	return w.err == nil
+}
+
+func (w *writer) flush() error {
+	if err := w.w.Flush(); w.err == nil {
+		w.err = err
+	}
+	return w.err
+}
+
+func run(reader source, w io.Writer, opts Options, fn Func) (Stats, error) {
+	out := &writer{w: bufio.NewWriterSize(w, 64<<10), opts: opts}
+
+	if opts.Jobs < 2 {
+		for {
+			line, err := reader.Next()
+			var res result
+			switch {
+			case err == io.EOF:
+				return out.stats, out.flush()
+			case errors.As(err, new(*LineTooLongError)):
+				res.err = err
+			case err != nil:
+				out.flush()
+				return out.stats, err
+			default:
+				res.out, res.ok, res.err = fn(line)
+			}
+			if !out.emit(line, res) {
+				return out.stats, out.flush()
+			}
+		}
+	}
+
+	jobs := make(chan *batch, opts.Jobs)
+	// order holds batches in input order, bounding the lines in flight
+	order := make(chan *batch, opts.Jobs*2)
+	results := make(chan *batch, opts.Jobs*2)
+	// stop is closed by the writer to end reading early
+	stop := make(chan struct{})
+
+	var wg sync.WaitGroup
+	for i := 0; i < opts.Jobs; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for b := range jobs {
+				for i, line := range b.lines {
+					if b.results[i].err != nil {
+						// framing error recorded by the reader
+						continue
+					}
+					out, ok, err := fn(line)
+					b.results[i] = result{out, ok, err}
+				}
+				if opts.Unordered {
+					results <- b
+				} else {
+					close(b.done)
+				}
+			}
+		}()
+	}
+
+	var readErr error
+	go func() {
+		defer func() {
+			close(jobs)
+			close(order)
+			wg.Wait()
+			close(results)
+		}()
+		b := newBatch()
+		send := func() {
+			if !opts.Unordered {
+				order <- b
+			}
+			jobs <- b
+		}
+		for {
+			select {
+			case <-stop:
+				return
+			default:
+			}
+			line, err := reader.Next()
+			if err == io.EOF {
+				break
+			}
+			if err != nil && !errors.As(err, new(*LineTooLongError)) {
+				readErr = err
+				break
+			}
+			b.results[len(b.lines)].err = err
+			b.lines = append(b.lines, line)
+			if len(b.lines) == batchSize {
+				send()
+				b = newBatch()
+			}
+		}
+		if len(b.lines) > 0 {
+			send()
+		}
+	}()
+
+	stopped := false
+	write := func(b *batch) {
+		for i, line := range b.lines {
+			if !stopped && !out.emit(line, b.results[i]) {
+				stopped = true
+				close(stop)
+			}
+		}
+	}
+	// keep draining after a stop so the reader and workers can finish
+	if opts.Unordered {
+		for b := range results {
+			write(b)
+		}
+	} else {
+		for b := range order {
+			<-b.done
+			write(b)
+		}
+		for range results {
+		}
+	}
+
+	err := out.flush()
+	if readErr != nil {
+		return out.stats, readErr
+	}
+	return out.stats, err
+}
+
+func newBatch() *batch {
+	return &batch{
+		lines:   make([]string, 0, batchSize),
+		results: make([]result, batchSize),
+		done:    make(chan struct{}),
+	}
+}
@@ -0,0 +1,127 @@
+// Package table rewrites a single column of CSV or TSV records, leaving
+// the other fields as they are.
+package table
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+
+	"github.com/dcilke/durl/pkg/stream"
+)
+
+// ErrColumnNotFound is returned when the header has no column of the
+// requested name.
+var ErrColumnNotFound = errors.New("not found in header")
+
+// Options configures Process.
+type Options struct {
+	// Comma is the field separator, ',' when zero.
+	Comma rune
+	// Column selects the field by header name or 1-based index.
+	Column string
+	// Header treats the first row as a header. It is implied when Column
+	// is a name.
+	Header bool
+	// Append names the columns Func adds after the last field.
+	Append []string
+	// FailFast stops at the first record Func fails on.
+	FailFast bool
+	// OnError, when set, is called with the error of each failed record,
+	// after the records before it are written.
+	OnError func(err error)
+}
+
+// Func rewrites a field and returns a value for each appended column.
+type Func func(field string) (string, []string, error)
+
+// Column parses a 1-based column index, reporting false for a name.
+func Column(s string) (int, bool, error) {
+	n, err := strconv.Atoi(s)
+	if err != nil {
+		return 0, false, nil
+	}
+	if n < 1 {
+		return 0, true, fmt.Errorf("column index %d must be at least 1", n)
+	}
+	return n - 1, true, nil
+}
+
+// Process reads records from r, rewrites the selected field of each with
+// fn and writes them to w. Records fn fails on are written unchanged, with
+// empty appended columns, and records too short to have the field are
+// written as they are.
+func Process(r io.Reader, w io.Writer, opts Options, fn Func) (stream.Stats, error) {
+	var stats stream.Stats
+	col, isIndex, err := Column(opts.Column)
+	if err != nil {
+		return stats, err
+	}
+
+	cr := csv.NewReader(r)
+	cw := csv.NewWriter(w)
+	if opts.Comma != 0 {
+		cr.Comma = opts.Comma
+		cw.Comma = opts.Comma
+	}
+	cr.FieldsPerRecord = -1
+	defer cw.Flush()
+
+	for row := 0; ; row++ {
+		record, err := cr.Read()
+		if err == io.EOF {
+			cw.Flush()
+			return stats, cw.Error()
+		}
+		if err != nil {
+			return stats, err
+		}
+
+		if row == 0 && (!isIndex || opts.Header) {
+			if !isIndex {
+				if col = indexOf(record, opts.Column); col < 0 {
+					return stats, fmt.Errorf("column %q %w", opts.Column, ErrColumnNotFound)
+				}
+			}
+			if err := cw.Write(append(record, opts.Append...)); err != nil {
+				return stats, err
+			}
+			continue
+		}
+
+		stats.Records++
+		extra := make([]string, len(opts.Append))
+		if col < len(record) {
+			field, values, err := fn(record[col])
+			if err != nil {
+				stats.Failed++
+				if opts.OnError != nil {
+					// flush first so errors appear in order with the output
+					cw.Flush()
+					opts.OnError(err)
+				}
+				if opts.FailFast {
+					stats.Stopped = true
+					return stats, cw.Error()
+				}
+			} else {
+				record[col] = field
+				copy(extra, values)
+			}
+		}
+		if err := cw.Write(append(record, extra...)); err != nil {
+			return stats, err
+		}
+	}
+}
+
+func indexOf(record []string, name string) int {
+	for i, field := range record {
+		if field == name {
+			return i
+		}
+	}
+	return -1
+}
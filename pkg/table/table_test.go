@@ -0,0 +1,74 @@
+package table
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+// upper rewrites a field to upper case and appends its length, failing on
+// fields starting with "!".
+func upper(field string) (string, []string, error) {
+	if strings.HasPrefix(field, "!") {
+		return "", nil, errors.New("bad " + field)
+	}
+	return strings.ToUpper(field), []string{strings.Repeat("x", len(field))}, nil
+}
+
+func TestProcess(t *testing.T) {
+	for _, tt := range []struct {
+		name string
+		opts Options
+		in   string
+		out  string
+	}{
+		{"index", Options{Column: "2"}, "1,a\n2,b\n", "1,A\n2,B\n"},
+		{"header", Options{Column: "2", Header: true}, "id,link\n1,a\n", "id,link\n1,A\n"},
+		{"by name", Options{Column: "link"}, "link,id\na,1\n", "link,id\nA,1\n"},
+		{"quoted fields", Options{Column: "2"}, "\"x,y\",\"a \"\"b\"\", c\"\n", "\"x,y\",\"A \"\"B\"\", C\"\n"},
+		{"multi-line field", Options{Column: "1"}, "\"a\nb\",c\n", "\"A\nB\",c\n"},
+		{"append", Options{Column: "link", Append: []string{"len"}}, "link\nab\n", "link,len\nAB,xx\n"},
+		{"short record", Options{Column: "3", Append: []string{"len"}}, "a\na,b,c\n", "a,\na,b,C,x\n"},
+		{"tsv", Options{Column: "2", Comma: '\t'}, "1\ta,b\n", "1\tA,B\n"},
+		{"failed record", Options{Column: "1", Append: []string{"len"}}, "a\n!b\nc\n", "A,x\n!b,\nC,x\n"},
+		{"empty", Options{Column: "1"}, "", ""},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			var out bytes.Buffer
+			_, err := Process(strings.NewReader(tt.in), &out, tt.opts, upper)
+			require.NoError(t, err)
+			require.Equal(t, tt.out, out.String())
+		})
+	}
+}
+
+func TestProcessErrors(t *testing.T) {
+	// errors are reported after the records before them are written
+	var out bytes.Buffer
+	opts := Options{Column: "1", OnError: func(err error) {
+		out.WriteString("error: " + err.Error() + "\n")
+	}}
+	stats, err := Process(strings.NewReader("a\n!b\nc\n!d\n"), &out, opts, upper)
+	require.NoError(t, err)
+	require.Equal(t, "A\nerror: bad !b\n!b\nC\nerror: bad !d\n!d\n", out.String())
+	require.Equal(t, 4, stats.Records)
+	require.Equal(t, 2, stats.Failed)
+
+	out.Reset()
+	opts.FailFast = true
+	stats, err = Process(strings.NewReader("a\n!b\nc\n"), &out, opts, upper)
+	require.NoError(t, err)
+	require.Equal(t, "A\nerror: bad !b\n", out.String())
+	require.True(t, stats.Stopped)
+}
+
+func TestProcessColumn(t *testing.T) {
+	_, err := Process(strings.NewReader("id,link\n"), &bytes.Buffer{}, Options{Column: "url"}, upper)
+	require.True(t, errors.Is(err, ErrColumnNotFound))
+
+	_, err = Process(strings.NewReader("a\n"), &bytes.Buffer{}, Options{Column: "0"}, upper)
+	require.Error(t, err)
+}
@@ -0,0 +1,73 @@
+package urlax
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Component identifies the part of a URL a string is escaped for. Each
+// component allows a different set of characters unescaped.
+type Component int
+
+const (
+	PathComponent        = Component(encodePath)           // a whole path, "/" is kept
+	PathSegmentComponent = Component(encodePathSegment)    // a single path segment, as url.PathEscape
+	HostComponent        = Component(encodeHost)           // a host name or IP literal
+	ZoneComponent        = Component(encodeZone)           // an IPv6 zone identifier
+	UserinfoComponent    = Component(encodeUserPassword)   // a username or password
+	QueryComponent       = Component(encodeQueryComponent) // a query key or value, as url.QueryEscape
+	FragmentComponent    = Component(encodeFragment)       // a fragment
+)
+
+var componentNames = map[Component]string{
+	PathComponent:        "path",
+	PathSegmentComponent: "path-segment",
+	HostComponent:        "host",
+	ZoneComponent:        "zone",
+	UserinfoComponent:    "userinfo",
+	QueryComponent:       "query",
+	FragmentComponent:    "fragment",
+}
+
+func (c Component) String() string {
+	if name, ok := componentNames[c]; ok {
+		return name
+	}
+	return fmt.Sprintf("Component(%d)", int(c))
+}
+
+// ParseComponent returns the component with the given name, as returned
+// by Component.String.
+func ParseComponent(name string) (Component, error) {
+	var names []string
+	for c := PathComponent; c <= FragmentComponent; c++ {
+		if c.String() == name {
+			return c, nil
+		}
+		names = append(names, c.String())
+	}
+	return 0, fmt.Errorf("unknown component %q, expected one of %s", name, strings.Join(names, ", "))
+}
+
+// UnescapeOptions configures Unescape.
+type UnescapeOptions struct {
+	// Lax returns a string with a malformed escape unchanged instead of
+	// failing, the way Parse keeps such components verbatim.
+	Lax bool
+}
+
+// Escape percent-encodes s so it can be placed in the given component of
+// a URL.
+func Escape(s string, c Component) string {
+	return escape(s, encoding(c))
+}
+
+// Unescape decodes the percent-escapes in s, which was taken from the given
+// component of a URL. For QueryComponent '+' is also decoded as a space.
+func Unescape(s string, c Component, opts UnescapeOptions) (string, error) {
+	u, err := unescape(s, encoding(c))
+	if err != nil && opts.Lax {
+		return s, nil
+	}
+	return u, err
+}
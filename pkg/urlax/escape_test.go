@@ -0,0 +1,66 @@
+package urlax
+
+import (
+	"net/url"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestEscape(t *testing.T) {
+	const in = "a b/c?d=e&f+g@h:i#j"
+	for c, out := range map[Component]string{
+		PathComponent:        (&url.URL{Path: in}).EscapedPath(),
+		PathSegmentComponent: url.PathEscape(in),
+		QueryComponent:       url.QueryEscape(in),
+		UserinfoComponent:    "a%20b%2Fc%3Fd=e&f+g%40h%3Ai%23j",
+		FragmentComponent:    "a%20b/c?d=e&f+g@h:i%23j",
+	} {
+		t.Run(c.String(), func(t *testing.T) {
+			s := Escape(in, c)
+			require.Equal(t, out, s)
+
+			back, err := Unescape(s, c, UnescapeOptions{})
+			require.NoError(t, err)
+			require.Equal(t, in, back)
+		})
+	}
+}
+
+func TestUnescape(t *testing.T) {
+	for _, tt := range []struct {
+		in   string
+		c    Component
+		lax  bool
+		out  string
+		fail bool
+	}{
+		{"a+b%20c", QueryComponent, false, "a b c", false},
+		{"a+b%20c", PathComponent, false, "a+b c", false},
+		{"100%", PathComponent, false, "", true},
+		{"100%", PathComponent, true, "100%", false},
+		{"a%zzb%20", FragmentComponent, true, "a%zzb%20", false},
+		{"%41", HostComponent, false, "", true},
+		{"%C3%A9", HostComponent, false, "é", false},
+	} {
+		t.Run(tt.c.String()+" "+tt.in, func(t *testing.T) {
+			s, err := Unescape(tt.in, tt.c, UnescapeOptions{Lax: tt.lax})
+			if tt.fail {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, tt.out, s)
+		})
+	}
+}
+
+func TestParseComponent(t *testing.T) {
+	for c := PathComponent; c <= FragmentComponent; c++ {
+		got, err := ParseComponent(c.String())
+		require.NoError(t, err)
+		require.Equal(t, c, got)
+	}
+	_, err := ParseComponent("scheme")
+	require.Error(t, err)
+}
@@ -0,0 +1,307 @@
+package urlax
+
+import (
+	"errors"
+	"net"
+	"strings"
+)
+
+// URL is a parsed URL that keeps the exact text of every component, so that
+// editing one component and calling String leaves the rest of the URL byte
+// for byte as it was written. Unlike url.URL it never normalizes escapes,
+// hex case or the userinfo encoding.
+type URL struct {
+	Scheme    Part
+	Authority bool // the URL has a "//" authority, even an empty one
+	User      Part // Present when the URL has userinfo, i.e. an "@"
+	Password  Part // Present when the userinfo has a ":"
+	Host      Part
+	Port      Part // Present when the host is followed by a ":"
+	Path      Part
+	Opaque    Part
+	Query     Part // the whole query, Value is the same as Raw
+	Fragment  Part // Present when the URL has a "#"
+}
+
+// Part is the text of a single URL component.
+type Part struct {
+	Raw     string // exact escaped text, without delimiters
+	Start   int    // byte offset of Raw in the parsed URL, -1 once changed
+	End     int    // byte offset just past Raw, -1 once changed
+	Present bool   // the component or its delimiter appears in the URL
+
+	comp Component // escaping rules, zero for none
+}
+
+// Value returns the decoded text of the part. Malformed escapes are kept
+// as written.
+func (p Part) Value() string {
+	if p.comp == 0 {
+		return p.Raw
+	}
+	v, _ := Unescape(p.Raw, p.comp, UnescapeOptions{Lax: true})
+	return v
+}
+
+// Changed reports whether the part was set after parsing.
+func (p Part) Changed() bool {
+	return p.Start < 0
+}
+
+func (p *Part) set(raw string) {
+	*p = Part{Raw: raw, Start: -1, End: -1, Present: true, comp: p.comp}
+}
+
+func (p *Part) clear() {
+	*p = Part{Start: -1, End: -1, comp: p.comp}
+}
+
+// ParseLossless parses rawURL like Parse, but into a URL that keeps the
+// source text of each component.
+func ParseLossless(rawURL string) (*URL, error) {
+	if _, err := Parse(rawURL); err != nil {
+		return nil, err
+	}
+	return split(rawURL), nil
+}
+
+// split finds the components of rawURL the way Parse does, without
+// validating them. It never fails.
+func split(rawURL string) *URL {
+	u := &URL{
+		User:     Part{comp: UserinfoComponent},
+		Password: Part{comp: UserinfoComponent},
+		Host:     Part{comp: HostComponent},
+		Path:     Part{comp: PathComponent},
+		Fragment: Part{comp: FragmentComponent},
+	}
+	take := func(p *Part, start, end int) {
+		*p = Part{Raw: rawURL[start:end], Start: start, End: end, Present: true, comp: p.comp}
+	}
+
+	end := len(rawURL)
+	if i := strings.IndexByte(rawURL, '#'); i >= 0 {
+		take(&u.Fragment, i+1, end)
+		end = i
+	}
+	pos := 0
+	if scheme, _, err := parseScheme(rawURL[:end]); err == nil && scheme != "" {
+		take(&u.Scheme, 0, len(scheme))
+		pos = len(scheme) + 1
+	}
+	if i := strings.IndexByte(rawURL[pos:end], '?'); i >= 0 {
+		take(&u.Query, pos+i+1, end)
+		end = pos + i
+	}
+
+	rest := rawURL[pos:end]
+	switch {
+	case u.Scheme.Present && rest != "" && !strings.HasPrefix(rest, "/"):
+		take(&u.Opaque, pos, end)
+	case (u.Scheme.Present || !strings.HasPrefix(rest, "///")) && strings.HasPrefix(rest, "//"):
+		u.Authority = true
+		start := pos + 2
+		path := end
+		if i := strings.IndexByte(rawURL[start:end], '/'); i >= 0 {
+			path = start + i
+		}
+		if path < end {
+			take(&u.Path, path, end)
+		}
+
+		host := start
+		if i := strings.LastIndex(rawURL[start:path], "@"); i >= 0 {
+			host = start + i + 1
+			if j := strings.IndexByte(rawURL[start:host-1], ':'); j >= 0 {
+				take(&u.User, start, start+j)
+				take(&u.Password, start+j+1, host-1)
+			} else {
+				take(&u.User, start, host-1)
+			}
+		}
+		// the port follows the last colon, unless it is inside IPv6 brackets
+		colon := strings.LastIndex(rawURL[host:path], ":")
+		if colon < strings.LastIndex(rawURL[host:path], "]") {
+			colon = -1
+		}
+		if colon < 0 {
+			take(&u.Host, host, path)
+		} else {
+			take(&u.Host, host, host+colon)
+			take(&u.Port, host+colon+1, path)
+		}
+	case rest != "":
+		take(&u.Path, pos, end)
+	}
+	return u
+}
+
+// String reassembles the URL. Parts that were not changed are written
+// exactly as they were parsed.
+func (u *URL) String() string {
+	var buf strings.Builder
+	if u.Scheme.Present {
+		buf.WriteString(u.Scheme.Raw)
+		buf.WriteByte(':')
+	}
+	if u.Authority {
+		buf.WriteString("//")
+		if u.User.Present {
+			buf.WriteString(u.User.Raw)
+			if u.Password.Present {
+				buf.WriteByte(':')
+				buf.WriteString(u.Password.Raw)
+			}
+			buf.WriteByte('@')
+		}
+		buf.WriteString(u.Host.Raw)
+		if u.Port.Present {
+			buf.WriteByte(':')
+			buf.WriteString(u.Port.Raw)
+		}
+	}
+	buf.WriteString(u.Opaque.Raw)
+	buf.WriteString(u.Path.Raw)
+	if u.Query.Present {
+		buf.WriteByte('?')
+		buf.WriteString(u.Query.Raw)
+	}
+	if u.Fragment.Present {
+		buf.WriteByte('#')
+		buf.WriteString(u.Fragment.Raw)
+	}
+	return buf.String()
+}
+
+var errOpaque = errors.New("cannot set the authority or path of an opaque URL")
+
+// SetScheme replaces the scheme. An empty scheme removes it.
+func (u *URL) SetScheme(scheme string) error {
+	if scheme == "" {
+		u.Scheme.clear()
+		return nil
+	}
+	if s, _, err := parseScheme(scheme + ":"); err != nil || s != scheme {
+		return errors.New("invalid scheme " + scheme)
+	}
+	u.Scheme.set(scheme)
+	return nil
+}
+
+// SetUser replaces the decoded username, adding userinfo when there is
+// none.
+func (u *URL) SetUser(user string) error {
+	if err := u.authority(); err != nil {
+		return err
+	}
+	u.User.set(Escape(user, UserinfoComponent))
+	return nil
+}
+
+// SetPassword replaces the decoded password, adding userinfo when there is
+// none.
+func (u *URL) SetPassword(password string) error {
+	if err := u.authority(); err != nil {
+		return err
+	}
+	if !u.User.Present {
+		u.User.set("")
+	}
+	u.Password.set(Escape(password, UserinfoComponent))
+	return nil
+}
+
+// SetHost replaces the decoded hostname, keeping the port. IPv6 addresses,
+// with or without a zone, are bracketed. Any other host with a ":" or
+// brackets is an error, the port is set with SetPort.
+func (u *URL) SetHost(host string) error {
+	if err := u.authority(); err != nil {
+		return err
+	}
+	if ip := strings.TrimSuffix(strings.TrimPrefix(host, "["), "]"); ip != host || strings.Contains(host, ":") {
+		addr, _, _ := strings.Cut(ip, "%")
+		if !strings.Contains(addr, ":") || net.ParseIP(addr) == nil {
+			return errors.New("invalid host " + host + ", expected a hostname or IPv6 address without a port")
+		}
+		host = "[" + ip + "]"
+	}
+	u.Host.set(Escape(host, HostComponent))
+	return nil
+}
+
+// SetPort replaces the port. An empty port removes it.
+func (u *URL) SetPort(port string) error {
+	if err := u.authority(); err != nil {
+		return err
+	}
+	if port == "" {
+		u.Port.clear()
+		return nil
+	}
+	for i := 0; i < len(port); i++ {
+		if port[i] < '0' || port[i] > '9' {
+			return errors.New("invalid port " + port)
+		}
+	}
+	u.Port.set(port)
+	return nil
+}
+
+// SetPath replaces the decoded path. With an authority the path is made
+// absolute.
+func (u *URL) SetPath(path string) error {
+	return u.SetRawPath(Escape(path, PathComponent))
+}
+
+// SetRawPath replaces the path with already escaped text.
+func (u *URL) SetRawPath(path string) error {
+	if u.Opaque.Present {
+		return errOpaque
+	}
+	if u.Authority && path != "" && !strings.HasPrefix(path, "/") {
+		path = "/" + path
+	}
+	u.Path.set(path)
+	return nil
+}
+
+// SetRawQuery replaces the query with already escaped text.
+func (u *URL) SetRawQuery(query string) {
+	u.Query.set(query)
+}
+
+// SetFragment replaces the decoded fragment.
+func (u *URL) SetFragment(fragment string) {
+	u.Fragment.set(Escape(fragment, FragmentComponent))
+}
+
+// StripUserinfo removes the username and password.
+func (u *URL) StripUserinfo() {
+	u.User.clear()
+	u.Password.clear()
+}
+
+// StripQuery removes the query, including the "?".
+func (u *URL) StripQuery() {
+	u.Query.clear()
+}
+
+// StripFragment removes the fragment, including the "#".
+func (u *URL) StripFragment() {
+	u.Fragment.clear()
+}
+
+// authority makes sure the URL has an authority that parts can be added
+// to.
+func (u *URL) authority() error {
+	if u.Opaque.Present {
+		return errOpaque
+	}
+	if !u.Authority {
+		u.Authority = true
+		if u.Path.Raw != "" && !strings.HasPrefix(u.Path.Raw, "/") {
+			u.Path.set("/" + u.Path.Raw)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,138 @@
+package urlax
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Profile reproduces the percent-encoding rules of a specific library
+// function, so the output of other ecosystems can be generated and read
+// back exactly.
+type Profile struct {
+	Name        string
+	Description string
+
+	// safe reports whether a byte is written as is.
+	safe func(c byte) bool
+	// plus encodes space as '+' and decodes '+' as space.
+	plus bool
+	// reserved lists the characters whose escapes decoding leaves alone.
+	reserved string
+	// strict makes decoding fail on malformed escapes instead of leaving
+	// them as is.
+	strict bool
+}
+
+// Profiles lists the known encoding profiles.
+var Profiles = []Profile{
+	{Name: "go-path", Description: "Go url.URL.EscapedPath", safe: goSafe(encodePath), strict: true},
+	{Name: "go-segment", Description: "Go url.PathEscape", safe: goSafe(encodePathSegment), strict: true},
+	{Name: "go-query", Description: "Go url.QueryEscape", safe: goSafe(encodeQueryComponent), plus: true, strict: true},
+	{Name: "go-userinfo", Description: "Go url.Userinfo.String", safe: goSafe(encodeUserPassword), strict: true},
+	{Name: "go-fragment", Description: "Go url.URL.EscapedFragment", safe: goSafe(encodeFragment), strict: true},
+	{Name: "go-host", Description: "Go host escaping", safe: goSafe(encodeHost), strict: true},
+	{Name: "js-uri", Description: "JavaScript encodeURI/decodeURI", safe: alnumOr("-_.!~*'();/?:@&=+$,#"), reserved: ";/?:@&=+$,#", strict: true},
+	{Name: "js-component", Description: "JavaScript encodeURIComponent/decodeURIComponent", safe: alnumOr("-_.!~*'()"), strict: true},
+	{Name: "python-quote", Description: "Python urllib.parse.quote/unquote", safe: alnumOr("-_.~/")},
+	{Name: "python-quote-plus", Description: "Python urllib.parse.quote_plus/unquote_plus", safe: alnumOr("-_.~"), plus: true},
+	{Name: "java", Description: "Java URLEncoder/URLDecoder", safe: alnumOr("-_.*"), plus: true, strict: true},
+	{Name: "php-raw", Description: "PHP rawurlencode/rawurldecode", safe: alnumOr("-_.~")},
+	{Name: "php", Description: "PHP urlencode/urldecode", safe: alnumOr("-_."), plus: true},
+	{Name: "curl", Description: "curl --data-urlencode (curl_easy_escape)", safe: alnumOr("-_.~")},
+	{Name: "whatwg-c0", Description: "WHATWG C0 control percent-encode set", safe: whatwgSafe("")},
+	{Name: "whatwg-fragment", Description: "WHATWG fragment percent-encode set", safe: whatwgSafe(whatwgFragment)},
+	{Name: "whatwg-query", Description: "WHATWG query percent-encode set", safe: whatwgSafe(whatwgQuery)},
+	{Name: "whatwg-special-query", Description: "WHATWG special-query percent-encode set", safe: whatwgSafe(whatwgQuery + "'")},
+	{Name: "whatwg-path", Description: "WHATWG path percent-encode set", safe: whatwgSafe(whatwgPath)},
+	{Name: "whatwg-userinfo", Description: "WHATWG userinfo percent-encode set", safe: whatwgSafe(whatwgUserinfo)},
+	{Name: "whatwg-component", Description: "WHATWG component percent-encode set", safe: whatwgSafe(whatwgComponent)},
+	{Name: "whatwg-form", Description: "WHATWG application/x-www-form-urlencoded", safe: whatwgSafe(whatwgComponent + "!'()~"), plus: true},
+}
+
+// Characters added to the C0 control percent-encode set by each WHATWG
+// set, see https://url.spec.whatwg.org/#percent-encoded-bytes.
+const (
+	whatwgFragment  = " \"<>`"
+	whatwgQuery     = " \"#<>"
+	whatwgPath      = whatwgQuery + "?^`{}"
+	whatwgUserinfo  = whatwgPath + "/:;=@[\\]|"
+	whatwgComponent = whatwgUserinfo + "$%&+,"
+)
+
+// LookupProfile returns the profile with the given name.
+func LookupProfile(name string) (Profile, error) {
+	var names []string
+	for _, p := range Profiles {
+		if p.Name == name {
+			return p, nil
+		}
+		names = append(names, p.Name)
+	}
+	return Profile{}, fmt.Errorf("unknown profile %q, expected one of %s", name, strings.Join(names, ", "))
+}
+
+func goSafe(mode encoding) func(byte) bool {
+	return func(c byte) bool {
+		return !shouldEscape(c, mode)
+	}
+}
+
+func alnumOr(extra string) func(byte) bool {
+	return func(c byte) bool {
+		return 'a' <= c && c <= 'z' || 'A' <= c && c <= 'Z' || '0' <= c && c <= '9' || strings.IndexByte(extra, c) >= 0
+	}
+}
+
+func whatwgSafe(encoded string) func(byte) bool {
+	return func(c byte) bool {
+		return c >= 0x20 && c < 0x7f && strings.IndexByte(encoded, c) < 0
+	}
+}
+
+// Escape percent-encodes s the way the profile's encoder does. Bytes of
+// multi-byte UTF-8 characters are escaped individually.
+func (p Profile) Escape(s string) string {
+	var t strings.Builder
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		switch {
+		case c == ' ' && p.plus:
+			t.WriteByte('+')
+		case p.safe(c):
+			t.WriteByte(c)
+		default:
+			t.WriteByte('%')
+			t.WriteByte(upperhex[c>>4])
+			t.WriteByte(upperhex[c&15])
+		}
+	}
+	return t.String()
+}
+
+// Unescape decodes s the way the profile's decoder does.
+func (p Profile) Unescape(s string) (string, error) {
+	var t strings.Builder
+	for i := 0; i < len(s); i++ {
+		switch c := s[i]; {
+		case c == '%' && i+2 < len(s) && ishex(s[i+1]) && ishex(s[i+2]):
+			v := unhex(s[i+1])<<4 | unhex(s[i+2])
+			if strings.IndexByte(p.reserved, v) >= 0 {
+				t.WriteString(s[i : i+3])
+			} else {
+				t.WriteByte(v)
+			}
+			i += 2
+		case c == '%' && p.strict:
+			s = s[i:]
+			if len(s) > 3 {
+				s = s[:3]
+			}
+			return "", EscapeError(s)
+		case c == '+' && p.plus:
+			t.WriteByte(' ')
+		default:
+			t.WriteByte(c)
+		}
+	}
+	return t.String(), nil
+}
@@ -0,0 +1,73 @@
+package urlax
+
+import (
+	"net/url"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+const profileInput = "a b/c?d=e&f+g!'()*~é#"
+
+func TestProfileEscape(t *testing.T) {
+	for name, out := range map[string]string{
+		"go-segment":        url.PathEscape(profileInput),
+		"go-query":          url.QueryEscape(profileInput),
+		"go-path":           (&url.URL{Path: profileInput}).EscapedPath(),
+		"js-uri":            "a%20b/c?d=e&f+g!'()*~%C3%A9#",
+		"js-component":      "a%20b%2Fc%3Fd%3De%26f%2Bg!'()*~%C3%A9%23",
+		"python-quote":      "a%20b/c%3Fd%3De%26f%2Bg%21%27%28%29%2A~%C3%A9%23",
+		"python-quote-plus": "a+b%2Fc%3Fd%3De%26f%2Bg%21%27%28%29%2A~%C3%A9%23",
+		"java":              "a+b%2Fc%3Fd%3De%26f%2Bg%21%27%28%29*%7E%C3%A9%23",
+		"php-raw":           "a%20b%2Fc%3Fd%3De%26f%2Bg%21%27%28%29%2A~%C3%A9%23",
+		"php":               "a+b%2Fc%3Fd%3De%26f%2Bg%21%27%28%29%2A%7E%C3%A9%23",
+		"curl":              "a%20b%2Fc%3Fd%3De%26f%2Bg%21%27%28%29%2A~%C3%A9%23",
+		"whatwg-path":       "a%20b/c%3Fd=e&f+g!'()*~%C3%A9%23",
+		"whatwg-form":       "a+b%2Fc%3Fd%3De%26f%2Bg%21%27%28%29*%7E%C3%A9%23",
+	} {
+		t.Run(name, func(t *testing.T) {
+			p, err := LookupProfile(name)
+			require.NoError(t, err)
+			s := p.Escape(profileInput)
+			require.Equal(t, out, s)
+
+			back, err := p.Unescape(s)
+			require.NoError(t, err)
+			require.Equal(t, profileInput, back)
+		})
+	}
+}
+
+func TestProfileUnescape(t *testing.T) {
+	for _, tt := range []struct {
+		profile, in, out string
+		err              bool
+	}{
+		{"js-uri", "%2F%20%3f%23", "%2F %3f%23", false},
+		{"js-component", "%2F%20", "/ ", false},
+		{"js-component", "100%", "", true},
+		{"python-quote", "100%zz+", "100%zz+", false},
+		{"python-quote-plus", "a+b%2B", "a b+", false},
+		{"php", "%4", "%4", false},
+		{"java", "%4", "", true},
+		{"go-query", "a+b", "a b", false},
+		{"go-segment", "a+b", "a+b", false},
+	} {
+		t.Run(tt.profile+" "+tt.in, func(t *testing.T) {
+			p, err := LookupProfile(tt.profile)
+			require.NoError(t, err)
+			s, err := p.Unescape(tt.in)
+			if tt.err {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, tt.out, s)
+		})
+	}
+}
+
+func TestLookupProfileUnknown(t *testing.T) {
+	_, err := LookupProfile("cobol")
+	require.Error(t, err)
+}
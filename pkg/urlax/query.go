@@ -0,0 +1,187 @@
+package urlax
+
+import (
+	"sort"
+	"strings"
+)
+
+// Param is a single query parameter, kept as written.
+type Param struct {
+	RawKey   string // escaped key
+	RawValue string // escaped value, without the "="
+	HasValue bool   // the parameter has a "=", telling "a=" from "a"
+}
+
+// NewParam escapes a decoded key and value into a parameter.
+func NewParam(key, value string) Param {
+	return Param{
+		RawKey:   Escape(key, QueryComponent),
+		RawValue: Escape(value, QueryComponent),
+		HasValue: true,
+	}
+}
+
+// Key returns the decoded key. Malformed escapes are kept as written.
+func (p Param) Key() string {
+	k, _ := Unescape(p.RawKey, QueryComponent, UnescapeOptions{Lax: true})
+	return k
+}
+
+// Value returns the decoded value. Malformed escapes are kept as written.
+func (p Param) Value() string {
+	v, _ := Unescape(p.RawValue, QueryComponent, UnescapeOptions{Lax: true})
+	return v
+}
+
+func (p Param) String() string {
+	if !p.HasValue {
+		return p.RawKey
+	}
+	return p.RawKey + "=" + p.RawValue
+}
+
+// Query is an ordered list of query parameters. Unlike url.Values it keeps
+// the order and position of repeated keys, empty parameters and keys
+// without "=", so String gives back exactly the text it was parsed from.
+type Query struct {
+	Params []Param
+}
+
+// ParseQuery splits a raw query, without the "?", into its parameters. It
+// never fails: malformed escapes are kept and only reported as written by
+// Key and Value.
+func ParseQuery(rawQuery string) *Query {
+	q := &Query{}
+	if rawQuery == "" {
+		return q
+	}
+	for _, kv := range strings.Split(rawQuery, "&") {
+		k, v, ok := strings.Cut(kv, "=")
+		q.Params = append(q.Params, Param{RawKey: k, RawValue: v, HasValue: ok})
+	}
+	return q
+}
+
+// String joins the parameters back into a raw query.
+func (q *Query) String() string {
+	var buf strings.Builder
+	for i, p := range q.Params {
+		if i > 0 {
+			buf.WriteByte('&')
+		}
+		buf.WriteString(p.String())
+	}
+	return buf.String()
+}
+
+// Get returns the decoded value of the first parameter with the decoded
+// key.
+func (q *Query) Get(key string) (string, bool) {
+	if i := q.index(key); i >= 0 {
+		return q.Params[i].Value(), true
+	}
+	return "", false
+}
+
+// Values returns the decoded values of every parameter with the decoded
+// key, in order.
+func (q *Query) Values(key string) []string {
+	var values []string
+	for _, p := range q.Params {
+		if p.Key() == key {
+			values = append(values, p.Value())
+		}
+	}
+	return values
+}
+
+// Has reports whether a parameter has the decoded key.
+func (q *Query) Has(key string) bool {
+	return q.index(key) >= 0
+}
+
+// Set replaces the first parameter with the key, keeping its position, and
+// removes the others. The parameter is appended when there is none.
+func (q *Query) Set(key, value string) {
+	i := q.index(key)
+	if i < 0 {
+		q.Add(key, value)
+		return
+	}
+	q.Params[i] = NewParam(key, value)
+	rest := q.Params[:i+1]
+	for _, p := range q.Params[i+1:] {
+		if p.Key() != key {
+			rest = append(rest, p)
+		}
+	}
+	q.Params = rest
+}
+
+// Add appends a parameter.
+func (q *Query) Add(key, value string) {
+	q.Params = append(q.Params, NewParam(key, value))
+}
+
+// Insert adds a parameter at position i, before the parameter currently
+// there.
+func (q *Query) Insert(i int, key, value string) {
+	q.Params = append(q.Params, Param{})
+	copy(q.Params[i+1:], q.Params[i:])
+	q.Params[i] = NewParam(key, value)
+}
+
+// Del removes every parameter with the decoded key.
+func (q *Query) Del(key string) {
+	rest := q.Params[:0]
+	for _, p := range q.Params {
+		if p.Key() != key {
+			rest = append(rest, p)
+		}
+	}
+	q.Params = rest
+}
+
+func (q *Query) index(key string) int {
+	for i, p := range q.Params {
+		if p.Key() == key {
+			return i
+		}
+	}
+	return -1
+}
+
+// CanonicalOptions configures Canonical.
+type CanonicalOptions struct {
+	Dedupe    bool // keep only the first of identical parameters
+	DropEmpty bool // drop parameters with an empty value, "a=" as well as "a"
+}
+
+// Canonical returns the parameters sorted by decoded key, keeping the
+// order of repeated keys, with keys and values re-escaped the way
+// url.QueryEscape does. Keys without "=" stay that way unless dropped, and
+// empty parameters, as in "a=1&&b=2", are always dropped.
+func (q *Query) Canonical(opts CanonicalOptions) *Query {
+	out := &Query{}
+	type pair struct {
+		key, value string
+		hasValue   bool
+	}
+	seen := map[pair]bool{}
+	for _, p := range q.Params {
+		kv := pair{p.Key(), p.Value(), p.HasValue}
+		if kv.key == "" && !kv.hasValue || opts.DropEmpty && kv.value == "" || opts.Dedupe && seen[kv] {
+			continue
+		}
+		seen[kv] = true
+		c := NewParam(kv.key, kv.value)
+		if !kv.hasValue {
+			c.RawValue, c.HasValue = "", false
+		}
+		out.Params = append(out.Params, c)
+	}
+	sort.SliceStable(out.Params, func(i, j int) bool {
+		return out.Params[i].Key() < out.Params[j].Key()
+	})
+	return out
+}
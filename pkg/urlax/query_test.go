@@ -0,0 +1,85 @@
+package urlax
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseQueryRoundTrip(t *testing.T) {
+	for _, in := range []string{
+		"",
+		"a=1",
+		"a=1&b=2&a=3",
+		"a=&b&=c",
+		"a=1&&b=2&",
+		"&",
+		"q=go%20lang+x%7e&r=%zz",
+		"k=v=w",
+	} {
+		t.Run(in, func(t *testing.T) {
+			require.Equal(t, in, ParseQuery(in).String())
+		})
+	}
+}
+
+func TestQuery(t *testing.T) {
+	q := ParseQuery("a=1&b&c=&a=2&d%20e=f+g&x=%zz")
+	require.Len(t, q.Params, 6)
+	require.Equal(t, Param{RawKey: "b"}, q.Params[1])
+	require.Equal(t, Param{RawKey: "c", HasValue: true}, q.Params[2])
+
+	v, ok := q.Get("a")
+	require.True(t, ok)
+	require.Equal(t, "1", v)
+	require.Equal(t, []string{"1", "2"}, q.Values("a"))
+	v, ok = q.Get("d e")
+	require.True(t, ok)
+	require.Equal(t, "f g", v)
+	v, _ = q.Get("x")
+	require.Equal(t, "%zz", v)
+	require.True(t, q.Has("b"))
+	require.True(t, !q.Has("z"))
+
+	q.Set("a", "x&y")
+	require.Equal(t, "a=x%26y&b&c=&d%20e=f+g&x=%zz", q.String())
+
+	q.Set("n", "new")
+	require.Equal(t, "a=x%26y&b&c=&d%20e=f+g&x=%zz&n=new", q.String())
+
+	q.Insert(0, "first", "1")
+	require.Equal(t, "first=1&a=x%26y&b&c=&d%20e=f+g&x=%zz&n=new", q.String())
+
+	q.Del("d e")
+	q.Del("c")
+	require.Equal(t, "first=1&a=x%26y&b&x=%zz&n=new", q.String())
+}
+
+func TestQueryCanonical(t *testing.T) {
+	for _, tt := range []struct {
+		name string
+		in   string
+		opts CanonicalOptions
+		out  string
+	}{
+		{"sort", "b=2&a=1&c=3", CanonicalOptions{}, "a=1&b=2&c=3"},
+		{"stable repeated keys", "b=2&a=z&b=1&a=y", CanonicalOptions{}, "a=z&a=y&b=2&b=1"},
+		{"keep duplicates", "a=1&a=1", CanonicalOptions{}, "a=1&a=1"},
+		{"dedupe", "a=1&b=2&a=1&a=2&b=2", CanonicalOptions{Dedupe: true}, "a=1&a=2&b=2"},
+		{"dedupe tells a from a=", "a&a=&a", CanonicalOptions{Dedupe: true}, "a&a="},
+		{"keep empty", "debug&b=&a=1", CanonicalOptions{}, "a=1&b=&debug"},
+		{"drop empty", "debug&b=&a=1", CanonicalOptions{DropEmpty: true}, "a=1"},
+		{"drop empty params", "a=1&&b=2&", CanonicalOptions{}, "a=1&b=2"},
+		{"unreserved", "q=%7e%2D%2e%5F", CanonicalOptions{}, "q=~-._"},
+		{"space", "q=a%20b&r=a+b", CanonicalOptions{}, "q=a+b&r=a+b"},
+		{"reserved", "q=a%2bb&k%3d=x/y?z", CanonicalOptions{}, "k%3D=x%2Fy%3Fz&q=a%2Bb"},
+		{"utf-8", "q=%c3%a9&p=é", CanonicalOptions{}, "p=%C3%A9&q=%C3%A9"},
+		{"malformed escape", "q=100%", CanonicalOptions{}, "q=100%25"},
+		{"sort by decoded key", "%62=2&a=1", CanonicalOptions{}, "a=1&b=2"},
+		{"empty", "", CanonicalOptions{}, ""},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			require.Equal(t, tt.out, ParseQuery(tt.in).Canonical(tt.opts).String())
+		})
+	}
+}
@@ -0,0 +1,82 @@
+package urlax
+
+// TokenKind identifies the part of a URL a Token covers.
+type TokenKind int
+
+const (
+	TokenDelim      TokenKind = iota // separators such as "://", "@", "?", "&" and "#"
+	TokenScheme                      // scheme, without the ":"
+	TokenUser                        // username
+	TokenPassword                    // password, without the ":"
+	TokenHost                        // hostname, including IPv6 brackets
+	TokenPort                        // port, without the ":"
+	TokenPath                        // path
+	TokenOpaque                      // opaque part of a URL with a rootless path
+	TokenQueryKey                    // key of a query parameter
+	TokenQueryValue                  // value of a query parameter, without the "="
+	TokenFragment                    // fragment, without the "#"
+)
+
+// Token is a run of the raw URL text that belongs to a single component.
+type Token struct {
+	Kind TokenKind
+	Text string
+}
+
+// Tokenize splits rawURL into the components Parse would find, keeping the
+// text exactly as written. Joining the Text of the tokens yields rawURL.
+// Tokenize never fails: text that Parse would reject is still assigned to
+// the component it appears in.
+func Tokenize(rawURL string) []Token {
+	u := split(rawURL)
+	var t tokens
+	if u.Scheme.Present {
+		t.add(TokenScheme, u.Scheme.Raw)
+		t.add(TokenDelim, ":")
+	}
+	if u.Authority {
+		t.add(TokenDelim, "//")
+		if u.User.Present {
+			t.add(TokenUser, u.User.Raw)
+			if u.Password.Present {
+				t.add(TokenDelim, ":")
+				t.add(TokenPassword, u.Password.Raw)
+			}
+			t.add(TokenDelim, "@")
+		}
+		t.add(TokenHost, u.Host.Raw)
+		if u.Port.Present {
+			t.add(TokenDelim, ":")
+			t.add(TokenPort, u.Port.Raw)
+		}
+	}
+	t.add(TokenOpaque, u.Opaque.Raw)
+	t.add(TokenPath, u.Path.Raw)
+	if u.Query.Present {
+		t.add(TokenDelim, "?")
+		for i, p := range ParseQuery(u.Query.Raw).Params {
+			if i > 0 {
+				t.add(TokenDelim, "&")
+			}
+			t.add(TokenQueryKey, p.RawKey)
+			if p.HasValue {
+				t.add(TokenDelim, "=")
+				t.add(TokenQueryValue, p.RawValue)
+			}
+		}
+	}
+	if u.Fragment.Present {
+		t.add(TokenDelim, "#")
+		t.add(TokenFragment, u.Fragment.Raw)
+	}
+	return t
+}
+
+type tokens []Token
+
+// add appends a token, dropping empty ones.
+func (t *tokens) add(kind TokenKind, text string) {
+	if text != "" {
+		*t = append(*t, Token{kind, text})
+	}
+}
@@ -0,0 +1,184 @@
+// Package urn parses and compares Uniform Resource Names as defined by
+// RFC 8141.
+package urn
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// URN is a parsed Uniform Resource Name:
+//
+//	urn:NID:NSS?+r-component?=q-component#f-component
+//
+// Components are kept exactly as written, including percent-encoding.
+type URN struct {
+	NID        string // namespace identifier
+	NSS        string // namespace specific string
+	RComponent string // resolution parameters, after "?+"
+	QComponent string // query parameters, after "?="
+	FComponent string // fragment, after "#"
+}
+
+// Parse parses s as a URN, validating the RFC 8141 syntax. The namespace
+// specific rules are checked separately by Validate.
+func Parse(s string) (*URN, error) {
+	if len(s) < 4 || !strings.EqualFold(s[:4], "urn:") {
+		return nil, errors.New("missing urn: prefix")
+	}
+	rest := s[4:]
+	u := &URN{}
+
+	var ok bool
+	if rest, u.FComponent, ok = strings.Cut(rest, "#"); ok {
+		if err := check("f-component", u.FComponent, "/?"); err != nil {
+			return nil, err
+		}
+	}
+	if i := strings.Index(rest, "?="); i >= 0 {
+		rest, u.QComponent = rest[:i], rest[i+2:]
+		if err := checkComponent("q-component", u.QComponent); err != nil {
+			return nil, err
+		}
+	}
+	if i := strings.Index(rest, "?+"); i >= 0 {
+		rest, u.RComponent = rest[:i], rest[i+2:]
+		if err := checkComponent("r-component", u.RComponent); err != nil {
+			return nil, err
+		}
+	}
+
+	if u.NID, u.NSS, ok = strings.Cut(rest, ":"); !ok {
+		return nil, errors.New("missing namespace specific string")
+	}
+	if err := checkNID(u.NID); err != nil {
+		return nil, err
+	}
+	if u.NSS == "" {
+		return nil, errors.New("empty namespace specific string")
+	}
+	if u.NSS[0] == '/' {
+		return nil, errors.New("namespace specific string cannot start with \"/\"")
+	}
+	if err := check("namespace specific string", u.NSS, "/"); err != nil {
+		return nil, err
+	}
+	return u, nil
+}
+
+// String reassembles the URN.
+func (u *URN) String() string {
+	var buf strings.Builder
+	buf.WriteString("urn:")
+	buf.WriteString(u.NID)
+	buf.WriteByte(':')
+	buf.WriteString(u.NSS)
+	if u.RComponent != "" {
+		buf.WriteString("?+")
+		buf.WriteString(u.RComponent)
+	}
+	if u.QComponent != "" {
+		buf.WriteString("?=")
+		buf.WriteString(u.QComponent)
+	}
+	if u.FComponent != "" {
+		buf.WriteByte('#')
+		buf.WriteString(u.FComponent)
+	}
+	return buf.String()
+}
+
+// Normalize returns the form used for RFC 8141 §3 equivalence: a lowercase
+// "urn:" prefix and NID, uppercase percent-encoding hex digits, and no r-,
+// q- or f-components.
+func (u *URN) Normalize() string {
+	return "urn:" + strings.ToLower(u.NID) + ":" + normalizePct(u.NSS)
+}
+
+// Equal reports whether u and v are equivalent per RFC 8141 §3.
+func (u *URN) Equal(v *URN) bool {
+	return u.Normalize() == v.Normalize()
+}
+
+// Validate checks the NSS against the rules of its namespace, when a
+// validator is registered for it.
+func (u *URN) Validate() error {
+	if v, ok := Validators[strings.ToLower(u.NID)]; ok {
+		if err := v(u.NSS); err != nil {
+			return fmt.Errorf("invalid %s URN: %w", strings.ToLower(u.NID), err)
+		}
+	}
+	return nil
+}
+
+// checkNID validates NID = (alphanum) 0*30(ldh) (alphanum).
+func checkNID(nid string) error {
+	if len(nid) < 2 || len(nid) > 32 {
+		return fmt.Errorf("namespace identifier %q must be 2 to 32 characters", nid)
+	}
+	for i := 0; i < len(nid); i++ {
+		c := nid[i]
+		if !isAlphaNum(c) && (c != '-' || i == 0 || i == len(nid)-1) {
+			return fmt.Errorf("invalid character %q in namespace identifier", c)
+		}
+	}
+	return nil
+}
+
+// checkComponent validates r- and q-components, which must start with a
+// pchar.
+func checkComponent(name, s string) error {
+	if s == "" || s[0] == '/' || s[0] == '?' {
+		return fmt.Errorf("%s must start with a path character", name)
+	}
+	return check(name, s, "/?")
+}
+
+// check validates that s contains only pchars and the extra characters.
+func check(name, s, extra string) error {
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		switch {
+		case c == '%':
+			if i+2 >= len(s) || !isHex(s[i+1]) || !isHex(s[i+2]) {
+				return fmt.Errorf("invalid percent-encoding in %s", name)
+			}
+			i += 2
+		case isAlphaNum(c), strings.IndexByte("-._~!$&'()*+,;=:@", c) >= 0, strings.IndexByte(extra, c) >= 0:
+		default:
+			return fmt.Errorf("invalid character %q in %s", c, name)
+		}
+	}
+	return nil
+}
+
+func normalizePct(s string) string {
+	if !strings.Contains(s, "%") {
+		return s
+	}
+	b := []byte(s)
+	for i := 0; i < len(b); i++ {
+		if b[i] == '%' && i+2 < len(b) {
+			b[i+1] = upper(b[i+1])
+			b[i+2] = upper(b[i+2])
+			i += 2
+		}
+	}
+	return string(b)
+}
+
+func upper(c byte) byte {
+	if 'a' <= c && c <= 'z' {
+		return c - 'a' + 'A'
+	}
+	return c
+}
+
+func isAlphaNum(c byte) bool {
+	return 'a' <= c && c <= 'z' || 'A' <= c && c <= 'Z' || '0' <= c && c <= '9'
+}
+
+func isHex(c byte) bool {
+	return '0' <= c && c <= '9' || 'a' <= c && c <= 'f' || 'A' <= c && c <= 'F'
+}
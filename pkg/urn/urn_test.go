@@ -0,0 +1,99 @@
+package urn
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestParse(t *testing.T) {
+	for in, out := range map[string]*URN{
+		"urn:isbn:0451450523":                           {NID: "isbn", NSS: "0451450523"},
+		"URN:UUID:6e8bc430-9c3a-11d9-9669-0800200c9a66": {NID: "UUID", NSS: "6e8bc430-9c3a-11d9-9669-0800200c9a66"},
+		"urn:ietf:rfc:3986":                             {NID: "ietf", NSS: "rfc:3986"},
+		"urn:example:a/b%2Fc?+res=1?=q=2/?x#frag/?":     {NID: "example", NSS: "a/b%2Fc", RComponent: "res=1", QComponent: "q=2/?x", FComponent: "frag/?"},
+		"urn:example:weather?=op=map&lat=39.56":         {NID: "example", NSS: "weather", QComponent: "op=map&lat=39.56"},
+		"urn:example:foo-bar-baz-qux#somepart":          {NID: "example", NSS: "foo-bar-baz-qux", FComponent: "somepart"},
+	} {
+		t.Run(in, func(t *testing.T) {
+			u, err := Parse(in)
+			require.NoError(t, err)
+			require.Equal(t, out, u)
+			require.Equal(t, in[4:], u.String()[4:])
+		})
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	for _, in := range []string{
+		"isbn:0451450523",
+		"urn:isbn",
+		"urn:a:b",
+		"urn:-bad:b",
+		"urn:bad-:b",
+		"urn:x_y:b",
+		"urn:example:",
+		"urn:example:/abs",
+		"urn:example:a b",
+		"urn:example:a%2",
+		"urn:example:a?+",
+		"urn:example:a?=/q",
+	} {
+		t.Run(in, func(t *testing.T) {
+			_, err := Parse(in)
+			require.Error(t, err)
+		})
+	}
+}
+
+func TestEqual(t *testing.T) {
+	for _, tt := range []struct {
+		a, b  string
+		equal bool
+	}{
+		{"urn:example:a123,z456", "URN:example:a123,z456", true},
+		{"urn:example:a123,z456", "urn:EXAMPLE:a123,z456", true},
+		{"urn:example:a123,z456", "urn:example:a123,z456?+abc", true},
+		{"urn:example:a123,z456", "urn:example:a123,z456?=xyz", true},
+		{"urn:example:a123,z456", "urn:example:a123,z456#789", true},
+		{"urn:example:a123%2Cz456", "urn:example:a123%2cz456", true},
+		{"urn:example:a123,z456", "urn:example:A123,z456", false},
+		{"urn:example:a123,z456", "urn:example:a123%2Cz456", false},
+		{"urn:example:a123,z456", "urn:example:a123,z456/foo", false},
+	} {
+		t.Run(tt.a+" "+tt.b, func(t *testing.T) {
+			a, err := Parse(tt.a)
+			require.NoError(t, err)
+			b, err := Parse(tt.b)
+			require.NoError(t, err)
+			require.Equal(t, tt.equal, a.Equal(b))
+		})
+	}
+}
+
+func TestValidate(t *testing.T) {
+	for in, valid := range map[string]bool{
+		"urn:uuid:6e8bc430-9c3a-11d9-9669-0800200c9a66": true,
+		"urn:uuid:6e8bc430-9c3a-11d9-9669":              false,
+		"urn:isbn:0451450523":                           true,
+		"urn:isbn:0-8044-2957-X":                        true,
+		"urn:isbn:0451450524":                           false,
+		"urn:isbn:978-0-306-40615-7":                    true,
+		"urn:isbn:978-0-306-40615-8":                    false,
+		"urn:isbn:12345":                                false,
+		"urn:ietf:rfc:3986":                             true,
+		"urn:IETF:bcp:47":                               true,
+		"urn:ietf:rfc:x":                                false,
+		"urn:example:anything":                          true,
+	} {
+		t.Run(in, func(t *testing.T) {
+			u, err := Parse(in)
+			require.NoError(t, err)
+			if valid {
+				require.NoError(t, u.Validate())
+			} else {
+				require.Error(t, u.Validate())
+			}
+		})
+	}
+}
@@ -0,0 +1,82 @@
+package urn
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// Validators holds the namespace specific checks used by Validate, keyed
+// by lowercase NID. Callers may register their own.
+var Validators = map[string]func(nss string) error{
+	"uuid": validateUUID,
+	"isbn": validateISBN,
+	"ietf": validateIETF,
+}
+
+var uuidRe = regexp.MustCompile(`^[0-9a-fA-F]{8}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{12}$`)
+
+// validateUUID checks the RFC 4122 string representation.
+func validateUUID(nss string) error {
+	if !uuidRe.MatchString(nss) {
+		return fmt.Errorf("%q is not of the form xxxxxxxx-xxxx-xxxx-xxxx-xxxxxxxxxxxx", nss)
+	}
+	return nil
+}
+
+// validateISBN checks the length and check digit of an ISBN-10 or
+// ISBN-13, ignoring hyphens and spaces.
+func validateISBN(nss string) error {
+	digits := strings.NewReplacer("-", "", " ", "").Replace(nss)
+	switch len(digits) {
+	case 10:
+		sum := 0
+		for i := 0; i < 10; i++ {
+			c := digits[i]
+			var d int
+			switch {
+			case '0' <= c && c <= '9':
+				d = int(c - '0')
+			case i == 9 && (c == 'X' || c == 'x'):
+				d = 10
+			default:
+				return fmt.Errorf("invalid ISBN-10 character %q", c)
+			}
+			sum += (10 - i) * d
+		}
+		if sum%11 != 0 {
+			return errors.New("ISBN-10 check digit does not match")
+		}
+	case 13:
+		sum := 0
+		for i := 0; i < 13; i++ {
+			c := digits[i]
+			if c < '0' || c > '9' {
+				return fmt.Errorf("invalid ISBN-13 character %q", c)
+			}
+			if i%2 == 0 {
+				sum += int(c - '0')
+			} else {
+				sum += 3 * int(c-'0')
+			}
+		}
+		if sum%10 != 0 {
+			return errors.New("ISBN-13 check digit does not match")
+		}
+	default:
+		return fmt.Errorf("ISBN must have 10 or 13 digits, got %d", len(digits))
+	}
+	return nil
+}
+
+var ietfRe = regexp.MustCompile(`(?i)^(?:(?:rfc|bcp|std|fyi):[1-9][0-9]*|id:[a-z0-9.-]+|mtg-[a-z0-9.-]+|params:.+)$`)
+
+// validateIETF checks the RFC 2648 forms: rfc, bcp, std, fyi, id, mtg and
+// params.
+func validateIETF(nss string) error {
+	if !ietfRe.MatchString(nss) {
+		return fmt.Errorf("%q is not an rfc, bcp, std, fyi, id, mtg or params name", nss)
+	}
+	return nil
+}
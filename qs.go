@@ -0,0 +1,43 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/dcilke/durl/pkg/qs"
+)
+
+type QueryToJSONCmd struct {
+	Comma bool `long:"comma" description:"Split values on commas into arrays"`
+}
+
+func (c *QueryToJSONCmd) Execute(args []string) error {
+	return inputs(args, func(arg string) (string, bool, error) {
+		// take the query of a URL, or the whole input as a raw query
+		query := arg
+		if _, q, ok := strings.Cut(arg, "?"); ok {
+			query, _, _ = strings.Cut(q, "#")
+		}
+		o, err := qs.Parse(query, qs.ParseOptions{Comma: c.Comma})
+		if err != nil {
+			return "", false, fmt.Errorf("unable to parse query %q: %w", query, err)
+		}
+		b, err := o.MarshalJSON()
+		return string(b), err == nil, err
+	})
+}
+
+type JSONToQueryCmd struct {
+	ArrayFormat string `long:"array-format" value-name:"FORMAT" choice:"brackets" choice:"indices" choice:"repeat" choice:"comma" default:"brackets" description:"How arrays are written: a[]=1&a[]=2, a[0]=1&a[1]=2, a=1&a=2 or a=1,2"`
+}
+
+func (c *JSONToQueryCmd) Execute(args []string) error {
+	format := qs.ArrayFormats[c.ArrayFormat]
+	return inputs(args, func(arg string) (string, bool, error) {
+		o, err := qs.Decode([]byte(arg))
+		if err != nil {
+			return "", false, fmt.Errorf("unable to parse json %q: %w", arg, err)
+		}
+		return qs.Stringify(o, format), true, nil
+	})
+}
@@ -0,0 +1,341 @@
+package main
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"strings"
+
+	"github.com/dcilke/durl/pkg/urlax"
+)
+
+// interactive runs an editing session on a single URL. The session is
+// written to stderr so that the URL it ends with, printed to stdout on
+// quit or end of input, can be captured or piped.
+func interactive(args []string) error {
+	if cmd.CSVOptions.enabled() || cmd.JSONPath != "" || cmd.TransformOptions.enabled() || cmd.ParamOptions.enabled() {
+		return usageError("--interactive cannot be combined with CSV, JSON, transform or query parameter options")
+	}
+	if len(args) > 1 {
+		return usageError("--interactive takes at most one URL")
+	}
+	s := &session{
+		out:    os.Stderr,
+		prompt: isTerminal(os.Stdin),
+		color:  cmd.OutputOptions.colorEnabledFor(func() bool { return isTerminal(os.Stderr) }),
+	}
+	if len(args) == 1 {
+		if err := s.load(args[0]); err != nil {
+			return &exitError{exitParse, err}
+		}
+	}
+	s.run(os.Stdin)
+	if s.url != "" {
+		fmt.Println(s.url)
+	}
+	return nil
+}
+
+// session is the state of an interactive session: the URL being edited,
+// kept byte for byte, and the versions before it for undo. URLs are
+// highlighted when color is set, as decided for out rather than stdout.
+type session struct {
+	out     io.Writer
+	prompt  bool
+	color   bool
+	url     string
+	history []string
+}
+
+type replCommand struct {
+	usage string
+	help  string
+	run   func(s *session, arg string) error
+}
+
+var replCommands map[string]replCommand
+
+func init() {
+	// set at init because help lists the commands
+	replCommands = map[string]replCommand{
+		"load":      {"load URL", "start over with URL; a line that is not a command does the same", (*session).load},
+		"show":      {"show", "break the URL down into its components", (*session).show},
+		"print":     {"print", "print the URL", (*session).print},
+		"get":       {"get COMPONENT | get param KEY", "print a decoded component or the values of a query parameter", (*session).get},
+		"set":       {"set COMPONENT VALUE | set param KEY VALUE", "replace a component with a decoded value, or the values of a query parameter", (*session).set},
+		"add":       {"add param KEY VALUE", "append a query parameter", (*session).add},
+		"del":       {"del COMPONENT | del param KEY", "remove userinfo, port, query or fragment, or the query parameters matching the glob KEY", (*session).del},
+		"decode":    {"decode", "print the fully decoded URL", (*session).decode},
+		"normalize": {"normalize", "re-encode the URL the way encode does and canonicalize its query", (*session).normalize},
+		"undo":      {"undo", "revert the last change", (*session).undo},
+		"help":      {"help", "list the commands", (*session).help},
+		"quit":      {"quit", "print the URL to stdout and exit", nil},
+	}
+}
+
+var replOrder = []string{"load", "show", "print", "get", "set", "add", "del", "decode", "normalize", "undo", "help", "quit"}
+
+func (s *session) run(in io.Reader) {
+	scanner := bufio.NewScanner(in)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	for {
+		if s.prompt {
+			fmt.Fprint(s.out, "durl> ")
+		}
+		if !scanner.Scan() {
+			if s.prompt {
+				fmt.Fprintln(s.out)
+			}
+			return
+		}
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		name, arg := cutWord(line)
+		name = strings.ToLower(name)
+		if name == "quit" || name == "exit" {
+			return
+		}
+		c, ok := replCommands[name]
+		if !ok {
+			if !strings.ContainsAny(line, ":/?#") {
+				fmt.Fprintf(s.out, "error: unknown command %q, try help\n", name)
+				continue
+			}
+			// a pasted URL
+			c, arg = replCommands["load"], line
+		}
+		if err := c.run(s, arg); err != nil {
+			fmt.Fprintf(s.out, "error: %v\n", err)
+		}
+	}
+}
+
+// cutWord splits the first word off line.
+func cutWord(line string) (word, rest string) {
+	word, rest, _ = strings.Cut(line, " ")
+	return word, strings.TrimLeft(rest, " ")
+}
+
+var errNoURL = errors.New("no URL loaded, paste one or use load URL")
+
+// edit replaces the URL with the result of fn, remembering the old one for
+// undo, and prints the new URL.
+func (s *session) edit(fn func(u *urlax.URL) error) error {
+	if s.url == "" {
+		return errNoURL
+	}
+	u, err := urlax.ParseLossless(s.url)
+	if err != nil {
+		return err
+	}
+	if err := fn(u); err != nil {
+		return err
+	}
+	return s.replace(u.String())
+}
+
+func (s *session) replace(raw string) error {
+	if raw == s.url {
+		fmt.Fprintln(s.out, "unchanged")
+		return nil
+	}
+	s.history = append(s.history, s.url)
+	s.url = raw
+	return s.print("")
+}
+
+func (s *session) load(arg string) error {
+	if arg == "" {
+		return errors.New("load requires a URL")
+	}
+	if _, err := urlax.ParseLossless(arg); err != nil {
+		return fmt.Errorf("unable to parse url %q: %w", arg, err)
+	}
+	if s.url != "" {
+		s.history = append(s.history, s.url)
+	}
+	s.url = arg
+	return s.show("")
+}
+
+func (s *session) show(string) error {
+	if s.url == "" {
+		return errNoURL
+	}
+	u, err := parseURL(s.url)
+	if err != nil {
+		return err
+	}
+	fmt.Fprintln(s.out, explainHeader(u, renderColor(u.String(), s.color)))
+	return nil
+}
+
+func (s *session) print(string) error {
+	if s.url == "" {
+		return errNoURL
+	}
+	fmt.Fprintln(s.out, renderColor(s.url, s.color))
+	return nil
+}
+
+func (s *session) get(arg string) error {
+	if s.url == "" {
+		return errNoURL
+	}
+	name, key := cutWord(arg)
+	if strings.EqualFold(name, "param") {
+		if key == "" {
+			return errors.New("get param requires a KEY")
+		}
+		u, _ := urlax.ParseLossless(s.url)
+		values := urlax.ParseQuery(u.Query.Raw).Values(key)
+		if len(values) == 0 {
+			return fmt.Errorf("no parameter %q", key)
+		}
+		for _, v := range values {
+			fmt.Fprintln(s.out, v)
+		}
+		return nil
+	}
+	u, err := parseURL(s.url)
+	if err != nil {
+		return err
+	}
+	v, err := component(u, name)
+	if err != nil {
+		return err
+	}
+	fmt.Fprintln(s.out, v)
+	return nil
+}
+
+func (s *session) set(arg string) error {
+	name, value := cutWord(arg)
+	if strings.EqualFold(name, "param") {
+		key, value := cutWord(value)
+		return s.editQuery(key, func(q *urlax.Query) { q.Set(key, value) })
+	}
+	// setting a component is the same as the matching --set-* transform
+	var o TransformOptions
+	if err := o.set(name, value); err != nil {
+		return err
+	}
+	return s.edit(o.edit)
+}
+
+func (s *session) add(arg string) error {
+	name, rest := cutWord(arg)
+	if !strings.EqualFold(name, "param") {
+		return errors.New("expected add param KEY VALUE")
+	}
+	key, value := cutWord(rest)
+	return s.editQuery(key, func(q *urlax.Query) { q.Add(key, value) })
+}
+
+func (s *session) del(arg string) error {
+	name, key := cutWord(arg)
+	return s.edit(func(u *urlax.URL) error {
+		switch strings.ToLower(name) {
+		case "param":
+			if key == "" {
+				return errors.New("del param requires a KEY")
+			}
+			if _, err := path.Match(key, ""); err != nil {
+				return fmt.Errorf("invalid pattern %q: %w", key, err)
+			}
+			q := urlax.ParseQuery(u.Query.Raw)
+			kept := q.Params[:0]
+			for _, p := range q.Params {
+				if ok, _ := path.Match(key, p.Key()); !ok {
+					kept = append(kept, p)
+				}
+			}
+			if len(kept) == len(q.Params) {
+				return fmt.Errorf("no parameter matching %q", key)
+			}
+			q.Params = kept
+			setQuery(u, q)
+		case "userinfo":
+			u.StripUserinfo()
+		case "port":
+			return u.SetPort("")
+		case "query":
+			u.StripQuery()
+		case "fragment":
+			u.StripFragment()
+		default:
+			return fmt.Errorf("cannot delete %q, expected userinfo, port, query, fragment or param KEY", name)
+		}
+		return nil
+	})
+}
+
+// editQuery applies fn to the parsed query of the URL.
+func (s *session) editQuery(key string, fn func(q *urlax.Query)) error {
+	if key == "" {
+		return errors.New("a parameter KEY is required")
+	}
+	return s.edit(func(u *urlax.URL) error {
+		q := urlax.ParseQuery(u.Query.Raw)
+		fn(q)
+		setQuery(u, q)
+		return nil
+	})
+}
+
+// setQuery stores q in u, removing the query when it is left empty.
+func setQuery(u *urlax.URL, q *urlax.Query) {
+	if len(q.Params) == 0 {
+		u.StripQuery()
+	} else {
+		u.SetRawQuery(q.String())
+	}
+}
+
+func (s *session) decode(string) error {
+	if s.url == "" {
+		return errNoURL
+	}
+	u, err := parseURL(s.url)
+	if err != nil {
+		return err
+	}
+	fmt.Fprintln(s.out, renderColor(urlax.Decode(u), s.color))
+	return nil
+}
+
+func (s *session) normalize(string) error {
+	if s.url == "" {
+		return errNoURL
+	}
+	u, err := parseURL(s.url)
+	if err != nil {
+		return err
+	}
+	if u.RawQuery != "" {
+		u.RawQuery = urlax.ParseQuery(u.RawQuery).Canonical(urlax.CanonicalOptions{}).String()
+	}
+	return s.replace(u.String())
+}
+
+func (s *session) undo(string) error {
+	if len(s.history) == 0 {
+		return errors.New("nothing to undo")
+	}
+	s.url = s.history[len(s.history)-1]
+	s.history = s.history[:len(s.history)-1]
+	return s.print("")
+}
+
+func (s *session) help(string) error {
+	for _, name := range replOrder {
+		c := replCommands[name]
+		fmt.Fprintf(s.out, "  %-42s %s\n", c.usage, c.help)
+	}
+	return nil
+}
@@ -0,0 +1,73 @@
+package main
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestSessionRun(t *testing.T) {
+	script := strings.Join([]string{
+		"# a pasted URL is loaded and shown",
+		"https://example.com/a?utm_source=x&b=2&utm_medium=y&a=1",
+		"set host example.org",
+		"del param utm_*",
+		"undo",
+		"normalize",
+		"get param b",
+		"bogus",
+		"print",
+		"quit",
+		"print",
+	}, "\n")
+	var out bytes.Buffer
+	s := &session{out: &out}
+	s.run(strings.NewReader(script))
+
+	lines := strings.Split(strings.TrimSuffix(out.String(), "\n"), "\n")
+	require.Equal(t, "https://example.com/a?utm_source=x&b=2&utm_medium=y&a=1", lines[0])
+	require.Equal(t, []string{
+		"https://example.org/a?utm_source=x&b=2&utm_medium=y&a=1",
+		"https://example.org/a?b=2&a=1",
+		"https://example.org/a?utm_source=x&b=2&utm_medium=y&a=1",
+		"https://example.org/a?a=1&b=2&utm_medium=y&utm_source=x",
+		"2",
+		`error: unknown command "bogus", try help`,
+		"https://example.org/a?a=1&b=2&utm_medium=y&utm_source=x",
+	}, lines[len(lines)-7:])
+	require.Equal(t, "https://example.org/a?a=1&b=2&utm_medium=y&utm_source=x", s.url)
+	require.Len(t, s.history, 2)
+}
+
+func TestSessionErrors(t *testing.T) {
+	for _, tt := range []struct {
+		name   string
+		script string
+		out    string
+	}{
+		{"no url", "print", "error: " + errNoURL.Error()},
+		{"nothing to undo", "undo", "error: nothing to undo"},
+		{"no match", "load https://h/?a=1\ndel param b*", `error: no parameter matching "b*"`},
+		{"bad pattern", "load https://h/?a=1\ndel param [", `error: invalid pattern "[": syntax error in pattern`},
+		{"unchanged", "load https://h/?a=1\nset param a 1", "unchanged"},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			var out bytes.Buffer
+			s := &session{out: &out}
+			s.run(strings.NewReader(tt.script))
+			lines := strings.Split(strings.TrimSuffix(out.String(), "\n"), "\n")
+			require.Equal(t, tt.out, lines[len(lines)-1])
+		})
+	}
+}
+
+func TestSessionColor(t *testing.T) {
+	for _, color := range []bool{false, true} {
+		var out bytes.Buffer
+		s := &session{out: &out, color: color}
+		s.run(strings.NewReader("https://example.com/?a=1\nprint\ndecode"))
+		require.Equal(t, color, strings.Contains(out.String(), "\x1b["))
+	}
+}
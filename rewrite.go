@@ -0,0 +1,83 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/dcilke/durl/pkg/rewrite"
+	"github.com/dcilke/durl/pkg/urlax"
+)
+
+type RewriteCmd struct {
+	Map   string `short:"m" long:"map" value-name:"FILE" required:"yes" description:"Tab separated mapping file of patterns and replacements"`
+	Match string `long:"match" value-name:"MODE" choice:"first" choice:"longest" default:"first" description:"Use the first matching rule or the one matching the most of the URL"`
+}
+
+func (c *RewriteCmd) Execute(args []string) error {
+	f, err := os.Open(c.Map)
+	if err != nil {
+		return fmt.Errorf("unable to read mapping: %w", err)
+	}
+	rules, err := rewrite.Parse(f)
+	f.Close()
+	if err != nil {
+		return usageError("unable to parse mapping %s: %w", c.Map, err)
+	}
+	rw := &rewrite.Rewriter{Rules: rules}
+	if c.Match == "longest" {
+		rw.Mode = rewrite.Longest
+	}
+
+	// unmatched counts the inputs no rule matched by host
+	var mu sync.Mutex
+	unmatched := map[string]int{}
+	err = inputs(args, func(arg string) (string, bool, error) {
+		out, rule, err := rw.Rewrite(arg)
+		if err != nil {
+			return "", false, fmt.Errorf("unable to parse url %q: %w", arg, err)
+		}
+		if rule == nil {
+			u, _ := urlax.ParseLossless(arg)
+			mu.Lock()
+			unmatched[u.Host.Value()]++
+			mu.Unlock()
+		}
+		return out, true, nil
+	})
+	reportUnmatched(unmatched)
+	return err
+}
+
+// reportUnmatched writes the number of unmatched inputs per host to stderr.
+func reportUnmatched(unmatched map[string]int) {
+	if len(unmatched) == 0 {
+		return
+	}
+	hosts := make([]string, 0, len(unmatched))
+	total := 0
+	for host, n := range unmatched {
+		hosts = append(hosts, host)
+		total += n
+	}
+	sort.Slice(hosts, func(i, j int) bool {
+		if unmatched[hosts[i]] != unmatched[hosts[j]] {
+			return unmatched[hosts[i]] > unmatched[hosts[j]]
+		}
+		return hosts[i] < hosts[j]
+	})
+
+	var buf strings.Builder
+	fmt.Fprintf(&buf, "%d inputs matched no rule", total)
+	for _, host := range hosts {
+		name := host
+		if name == "" {
+			name = "(no host)"
+		}
+		fmt.Fprintf(&buf, "\n  %d\t%s", unmatched[host], name)
+	}
+	printError(errors.New(buf.String()))
+}
@@ -0,0 +1,51 @@
+package main
+
+import (
+	"runtime"
+
+	"github.com/dcilke/durl/pkg/stream"
+)
+
+type StreamOptions struct {
+	Jobs          int  `short:"j" long:"jobs" value-name:"N" description:"Number of workers processing stdin (default: number of CPUs)"`
+	Unordered     bool `long:"unordered" description:"Write results as they complete instead of in input order"`
+	Null          bool `short:"0" long:"null" description:"Separate input and output records with NUL instead of newline"`
+	MaxLineLength int  `long:"max-line-length" value-name:"BYTES" description:"Skip and report input records longer than BYTES (default: unlimited)"`
+
+	FailFast    bool    `long:"fail-fast" description:"Stop at the first input that fails"`
+	KeepGoing   bool    `long:"keep-going" description:"Process every input and report failures at the end (default)"`
+	Placeholder *string `long:"placeholder" value-name:"TEXT" optional:"yes" optional-value:"" description:"Print TEXT (default: an empty line) for each failed input so output stays aligned with input"`
+}
+
+func (o *StreamOptions) validate() error {
+	if o.FailFast && o.KeepGoing {
+		return usageError("--fail-fast and --keep-going are mutually exclusive")
+	}
+	return nil
+}
+
+// delimiter returns the text that separates output records.
+func (o *StreamOptions) delimiter() string {
+	if o.Null {
+		return "\x00"
+	}
+	return "\n"
+}
+
+func (o *StreamOptions) options() stream.Options {
+	jobs := o.Jobs
+	if jobs <= 0 {
+		jobs = runtime.NumCPU()
+	}
+	return stream.Options{
+		Jobs:          jobs,
+		Unordered:     o.Unordered,
+		Null:          o.Null,
+		MaxLineLength: o.MaxLineLength,
+		FailFast:      o.FailFast,
+		Placeholder:   o.Placeholder,
+		OnError: func(line string, err error) {
+			printError(err)
+		},
+	}
+}
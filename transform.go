@@ -0,0 +1,140 @@
+package main
+
+import (
+	"fmt"
+	"net"
+	"strings"
+
+	"github.com/dcilke/durl/pkg/urlax"
+)
+
+type TransformOptions struct {
+	SetScheme   *string `long:"set-scheme" value-name:"SCHEME" description:"Replace the scheme"`
+	SetUser     *string `long:"set-user" value-name:"USER" description:"Replace the decoded username"`
+	SetPassword *string `long:"set-password" value-name:"PASSWORD" description:"Replace the decoded password"`
+	SetHost     *string `long:"set-host" value-name:"HOST" description:"Replace the decoded hostname, keeping the port"`
+	SetPort     *string `long:"set-port" value-name:"PORT" description:"Replace the port, an empty PORT removes it"`
+	SetPath     *string `long:"set-path" value-name:"PATH" description:"Replace the decoded path"`
+	SetQuery    *string `long:"set-query" value-name:"QUERY" description:"Replace the query with already escaped text"`
+	SetFragment *string `long:"set-fragment" value-name:"FRAGMENT" description:"Replace the decoded fragment"`
+
+	StripUserinfo bool `long:"strip-userinfo" description:"Remove the username and password"`
+	StripQuery    bool `long:"strip-query" description:"Remove the query"`
+	StripFragment bool `long:"strip-fragment" description:"Remove the fragment"`
+
+	CanonicalQuery bool `long:"canonical-query" description:"Sort query parameters by key and re-escape them consistently"`
+	DedupeQuery    bool `long:"dedupe-query" description:"With --canonical-query, also drop repeated identical parameters"`
+	DropEmpty      bool `long:"drop-empty" description:"With --canonical-query, also drop parameters with an empty value or without \"=\""`
+}
+
+// enabled reports whether any transform was requested.
+func (o *TransformOptions) enabled() bool {
+	return o.StripUserinfo || o.StripQuery || o.StripFragment || o.CanonicalQuery || o.SetScheme != nil || o.SetUser != nil ||
+		o.SetPassword != nil || o.SetHost != nil || o.SetPort != nil || o.SetPath != nil ||
+		o.SetQuery != nil || o.SetFragment != nil
+}
+
+// validate reports values no URL could be given, such as a port that is
+// not a number.
+func (o *TransformOptions) validate() error {
+	if o.DedupeQuery && !o.CanonicalQuery {
+		return usageError("--dedupe-query requires --canonical-query")
+	}
+	if o.DropEmpty && !o.CanonicalQuery {
+		return usageError("--drop-empty requires --canonical-query")
+	}
+	u, _ := urlax.ParseLossless("http://example.com/")
+	if err := o.edit(u); err != nil {
+		return usageError("%w", err)
+	}
+	return nil
+}
+
+// set records the transform for the named component, as given to the set
+// command.
+func (o *TransformOptions) set(name, value string) error {
+	switch strings.ToLower(name) {
+	case "scheme":
+		o.SetScheme = &value
+	case "user", "username":
+		o.SetUser = &value
+	case "password":
+		o.SetPassword = &value
+	case "host":
+		hostname, port, err := net.SplitHostPort(value)
+		if err != nil {
+			hostname, port = strings.TrimSuffix(strings.TrimPrefix(value, "["), "]"), ""
+		}
+		o.SetHost, o.SetPort = &hostname, &port
+	case "hostname":
+		o.SetHost = &value
+	case "port":
+		o.SetPort = &value
+	case "path":
+		o.SetPath = &value
+	case "query":
+		o.SetQuery = &value
+	case "fragment":
+		o.SetFragment = &value
+	default:
+		return fmt.Errorf("cannot set component %q, expected one of scheme, user, password, host, hostname, port, path, query, fragment", name)
+	}
+	return nil
+}
+
+// apply rewrites a URL, re-escaping only the components that change so
+// the rest of the URL is kept byte for byte.
+func (o *TransformOptions) apply(raw string) (string, error) {
+	u, err := urlax.ParseLossless(raw)
+	if err != nil {
+		return "", fmt.Errorf("unable to parse url %q: %w", raw, err)
+	}
+	if err := o.edit(u); err != nil {
+		return "", fmt.Errorf("unable to transform url %q: %w", raw, err)
+	}
+	return u.String(), nil
+}
+
+// edit applies the transforms to u, stripping components before setting
+// them.
+func (o *TransformOptions) edit(u *urlax.URL) error {
+	if o.StripUserinfo {
+		u.StripUserinfo()
+	}
+	if o.StripQuery {
+		u.StripQuery()
+	}
+	if o.StripFragment {
+		u.StripFragment()
+	}
+	for _, s := range []struct {
+		name  string
+		value *string
+		set   func(string) error
+	}{
+		{"scheme", o.SetScheme, u.SetScheme},
+		{"user", o.SetUser, u.SetUser},
+		{"password", o.SetPassword, u.SetPassword},
+		{"host", o.SetHost, u.SetHost},
+		{"port", o.SetPort, u.SetPort},
+		{"path", o.SetPath, u.SetPath},
+		{"query", o.SetQuery, func(q string) error { u.SetRawQuery(q); return nil }},
+		{"fragment", o.SetFragment, func(f string) error { u.SetFragment(f); return nil }},
+	} {
+		if s.value == nil {
+			continue
+		}
+		if err := s.set(*s.value); err != nil {
+			return fmt.Errorf("unable to set %s: %w", s.name, err)
+		}
+	}
+	if o.CanonicalQuery && u.Query.Present {
+		opts := urlax.CanonicalOptions{Dedupe: o.DedupeQuery, DropEmpty: o.DropEmpty}
+		if q := urlax.ParseQuery(u.Query.Raw).Canonical(opts).String(); q != "" {
+			u.SetRawQuery(q)
+		} else {
+			u.StripQuery()
+		}
+	}
+	return nil
+}
@@ -0,0 +1,55 @@
+package main
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/dcilke/durl/pkg/urn"
+)
+
+type UrnCmd struct {
+	Equal string `long:"equal" value-name:"URN" description:"Report whether each input is equivalent to URN"`
+}
+
+func (c *UrnCmd) Execute(args []string) error {
+	var other *urn.URN
+	if c.Equal != "" {
+		var err error
+		if other, err = urn.Parse(c.Equal); err != nil {
+			return usageError("unable to parse --equal urn %q: %w", c.Equal, err)
+		}
+	}
+	return inputs(args, func(arg string) (string, bool, error) {
+		u, err := urn.Parse(arg)
+		if err != nil {
+			return "", false, fmt.Errorf("unable to parse urn %q: %w", arg, err)
+		}
+		if other != nil {
+			return strconv.FormatBool(u.Equal(other)), true, nil
+		}
+
+		var buf strings.Builder
+		w := tabwriter.NewWriter(&buf, 0, 4, 2, ' ', 0)
+		fmt.Fprintf(w, "nid\t%s\n", u.NID)
+		fmt.Fprintf(w, "nss\t%s\n", u.NSS)
+		if u.RComponent != "" {
+			fmt.Fprintf(w, "r-component\t%s\n", u.RComponent)
+		}
+		if u.QComponent != "" {
+			fmt.Fprintf(w, "q-component\t%s\n", u.QComponent)
+		}
+		if u.FComponent != "" {
+			fmt.Fprintf(w, "f-component\t%s\n", u.FComponent)
+		}
+		fmt.Fprintf(w, "normalized\t%s\n", u.Normalize())
+		if err := u.Validate(); err != nil {
+			fmt.Fprintf(w, "valid\tfalse (%s)\n", err)
+		} else {
+			fmt.Fprintf(w, "valid\ttrue\n")
+		}
+		w.Flush()
+		return strings.TrimSuffix(buf.String(), "\n"), true, nil
+	})
+}